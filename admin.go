@@ -0,0 +1,183 @@
+package relog
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// AdminHandler is an http.Handler which lists the registered receivers and changes their verbosities.
+//
+//	GET  <prefix>                               lists every registered receiver
+//	GET  <prefix><name>                         describes the named receiver
+//	PUT  <prefix><name>?verbosity=debug&ttl=5m  sets the named receiver's verbosity, optionally reverting it after ttl
+//
+// Receivers are described as JSON objects with their type, and with their verbosity, severity name, prefix and flags
+// for Relays, Collectors and other receivers that report them. Verbosities are given by severity name, e.g. "warning",
+// or number, either in the URL query or as form values in the body of a PUT or POST request.
+type AdminHandler struct {
+	prefix string
+
+	mu      sync.Mutex
+	reverts map[string]*adminRevert
+}
+
+// adminRevert is a pending restoration of a receiver's verbosity.
+type adminRevert struct {
+	timer     *time.Timer
+	verbosity int
+	inherited bool // the receiver is a named child that took its verbosity from its parent, and is to again
+	at        time.Time
+}
+
+// adminReceiver describes a receiver in AdminHandler responses.
+type adminReceiver struct {
+	Type      string     `json:"type"`
+	Verbosity *int       `json:"verbosity,omitempty"`
+	Severity  string     `json:"severity,omitempty"`
+	Prefix    *string    `json:"prefix,omitempty"`
+	Flags     *int       `json:"flags,omitempty"`
+	RevertAt  *time.Time `json:"revert_at,omitempty"`
+}
+
+// NewAdminHandler creates a new AdminHandler to be served at prefix, e.g. "/debug/relog/".
+func NewAdminHandler(prefix string) *AdminHandler {
+	return &AdminHandler{prefix: prefix, reverts: make(map[string]*adminRevert)}
+}
+
+// ServeHTTP lists or describes receivers on GET, and changes a receiver's verbosity on PUT or POST.
+func (h *AdminHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	name := strings.TrimPrefix(req.URL.Path, h.prefix)
+	switch req.Method {
+	case http.MethodGet, http.MethodHead:
+		if name == "" {
+			list := make(map[string]adminReceiver)
+			for _, name := range Registered() {
+				if rcvr := Lookup(name); rcvr != nil {
+					list[name] = h.describe(name, rcvr)
+				}
+			}
+			writeJSON(w, list)
+			return
+		}
+		rcvr := Lookup(name)
+		if rcvr == nil {
+			http.Error(w, fmt.Sprintf("relog: no receiver %q", name), http.StatusNotFound)
+			return
+		}
+		writeJSON(w, h.describe(name, rcvr))
+	case http.MethodPut, http.MethodPost:
+		rcvr := Lookup(name)
+		if rcvr == nil {
+			http.Error(w, fmt.Sprintf("relog: no receiver %q", name), http.StatusNotFound)
+			return
+		}
+		if err := h.setVerbosity(name, rcvr, req.FormValue("verbosity"), req.FormValue("ttl")); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		writeJSON(w, h.describe(name, rcvr))
+	default:
+		w.Header().Set("Allow", "GET, HEAD, PUT, POST")
+		http.Error(w, "relog: method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// setVerbosity sets rcvr's verbosity to the severity named by value, scheduling the previous verbosity
+// to be restored after ttl if ttl is not empty. A change cancels any restoration already scheduled for name,
+// but a restoration always returns to the verbosity before the first change it replaced.
+// A named child which inherited its verbosity from its parent before the first change inherits it again.
+func (h *AdminHandler) setVerbosity(name string, rcvr Receiver, value string, ttl string) error {
+	severity, err := ParseSeverity(value)
+	if err != nil {
+		return err
+	}
+	verbosity := int(severity)
+	var d time.Duration
+	if ttl != "" {
+		if d, err = time.ParseDuration(ttl); err != nil || d <= 0 {
+			return fmt.Errorf("relog: invalid ttl %q", ttl)
+		}
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	previous, ok := h.reverts[name]
+	if ok {
+		previous.timer.Stop()
+		delete(h.reverts, name)
+	}
+	if d > 0 {
+		v, hasVerbosity := rcvr.(interface{ Verbosity() int })
+		if !hasVerbosity && !ok {
+			return fmt.Errorf("relog: receiver %q doesn't report its verbosity, so can't be reverted", name)
+		}
+		revert := &adminRevert{at: time.Now().Add(d)}
+		if ok {
+			revert.verbosity, revert.inherited = previous.verbosity, previous.inherited
+		} else {
+			revert.verbosity = v.Verbosity()
+			if r, isRelay := rcvr.(*Relay); isRelay {
+				revert.inherited = r.inherits(ownVerbosity)
+			}
+		}
+		revert.timer = time.AfterFunc(d, func() {
+			h.mu.Lock()
+			defer h.mu.Unlock()
+			if h.reverts[name] == revert {
+				delete(h.reverts, name)
+				if revert.inherited {
+					rcvr.(*Relay).clearOwn(ownVerbosity)
+				} else {
+					rcvr.SetVerbosity(revert.verbosity)
+				}
+			}
+		})
+		h.reverts[name] = revert
+	}
+	rcvr.SetVerbosity(verbosity)
+	return nil
+}
+
+// describe returns the description of rcvr.
+func (h *AdminHandler) describe(name string, rcvr Receiver) adminReceiver {
+	var d adminReceiver
+	switch rcvr.(type) {
+	case *Relay:
+		d.Type = "relay"
+	case *Collector:
+		d.Type = "collector"
+	default:
+		d.Type = strings.TrimPrefix(fmt.Sprintf("%T", rcvr), "*relog.")
+	}
+	if v, ok := rcvr.(interface{ Verbosity() int }); ok {
+		verbosity := v.Verbosity()
+		d.Verbosity = &verbosity
+		if definedSeverity(verbosity) {
+			d.Severity = severityLabel(verbosity)
+		}
+	}
+	if p, ok := rcvr.(interface{ Prefix() string }); ok {
+		prefix := p.Prefix()
+		d.Prefix = &prefix
+	}
+	if f, ok := rcvr.(interface{ Flags() int }); ok {
+		flags := f.Flags()
+		d.Flags = &flags
+	}
+	h.mu.Lock()
+	if revert, ok := h.reverts[name]; ok {
+		at := revert.at
+		d.RevertAt = &at
+	}
+	h.mu.Unlock()
+	return d
+}
+
+// writeJSON writes v to w as JSON.
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,121 @@
+package relog
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+var AdminTests = []struct {
+	method string
+	path   string
+	code   int
+	exp    string
+}{
+	{"GET", "/debug/relog/", 200, `{"admin.db":{"type":"relay","verbosity":6,"severity":"INFO","prefix":"db","flags":0},` +
+		`"admin.out":{"type":"collector","verbosity":7,"severity":"DEBUG","prefix":"","flags":3}}` + "\n"},
+	{"GET", "/debug/relog/admin.db", 200, `{"type":"relay","verbosity":6,"severity":"INFO","prefix":"db","flags":0}` + "\n"},
+	{"PUT", "/debug/relog/admin.db?verbosity=warning", 200, `{"type":"relay","verbosity":4,"severity":"WARNING","prefix":"db","flags":0}` + "\n"},
+	{"POST", "/debug/relog/admin.out?verbosity=3", 200, `{"type":"collector","verbosity":3,"severity":"ERROR","prefix":"","flags":3}` + "\n"},
+	{"PUT", "/debug/relog/admin.db?verbosity=loud", 400, `relog: invalid severity "loud"` + "\n"},
+	{"PUT", "/debug/relog/admin.db?verbosity=debug&ttl=-1s", 400, `relog: invalid ttl "-1s"` + "\n"},
+	{"PUT", "/debug/relog/admin.none?verbosity=debug", 404, `relog: no receiver "admin.none"` + "\n"},
+	{"DELETE", "/debug/relog/admin.db", 405, "relog: method not allowed\n"},
+}
+
+func TestAdminHandler(t *testing.T) {
+	var output bytes.Buffer
+	relay := New(LInfo, "db", 0)
+	collector := NewCollector(&output, LDebug, "", LstdFlags)
+	relay.AddReceiver(collector)
+	Register("admin.db", relay)
+	Register("admin.out", collector)
+	defer Unregister("admin.db")
+	defer Unregister("admin.out")
+
+	h := NewAdminHandler("/debug/relog/")
+	for _, test := range AdminTests {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(test.method, test.path, nil))
+		result := rec.Body.String()
+		if test.path == "/debug/relog/" {
+			result = adminEntries(t, result)
+		}
+		if rec.Code != test.code || result != test.exp {
+			t.Errorf("%s %s response didn't match\nEXP: %d %s^\nGOT: %d %s^", test.method, test.path, test.code, test.exp, rec.Code, result)
+		}
+	}
+}
+
+// adminEntries returns the listing with only the receivers registered by TestAdminHandler,
+// leaving out any registered by other tests.
+func adminEntries(t *testing.T, listing string) string {
+	var list map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(listing), &list); err != nil {
+		return listing
+	}
+	for name := range list {
+		if name != "admin.db" && name != "admin.out" {
+			delete(list, name)
+		}
+	}
+	b, err := json.Marshal(list)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b) + "\n"
+}
+
+func TestAdminHandlerTTL(t *testing.T) {
+	relay := New(LInfo, "", 0)
+	Register("admin.ttl", relay)
+	defer Unregister("admin.ttl")
+	h := NewAdminHandler("/")
+
+	put := func(body string) {
+		req := httptest.NewRequest("PUT", "/admin.ttl", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"revert_at"`) {
+			t.Fatalf("PUT %s failed: %d %s", body, rec.Code, rec.Body)
+		}
+	}
+	put("verbosity=debug&ttl=1h")
+	put("verbosity=notice&ttl=20ms") // replaces the first revert, but still reverts to Info
+	if v := relay.Verbosity(); v != LNotice {
+		t.Errorf("Verbosity didn't match\nEXP: %d^\nGOT: %d^", LNotice, v)
+	}
+	for deadline := time.Now().Add(5 * time.Second); relay.Verbosity() != LInfo; time.Sleep(time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatalf("Verbosity not reverted\nEXP: %d^\nGOT: %d^", LInfo, relay.Verbosity())
+		}
+	}
+}
+
+func TestAdminHandlerTTLInherited(t *testing.T) {
+	parent := New(LInfo, "", 0)
+	child := parent.Named("child")
+	Register("admin.child", child)
+	defer Unregister("admin.child")
+	h := NewAdminHandler("/")
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("PUT", "/admin.child?verbosity=debug&ttl=20ms", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("PUT failed: %d %s", rec.Code, rec.Body)
+	}
+	for deadline := time.Now().Add(5 * time.Second); !child.inherits(ownVerbosity); time.Sleep(time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatalf("Verbosity not reverted to inheritance, still %d", child.Verbosity())
+		}
+	}
+	parent.SetVerbosity(LWarn)
+	if v := child.Verbosity(); v != LWarn {
+		t.Errorf("Verbosity didn't follow parent\nEXP: %d^\nGOT: %d^", LWarn, v)
+	}
+}
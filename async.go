@@ -0,0 +1,200 @@
+package relog
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// AsyncReceiver policies for handling messages logged while the queue is full.
+const (
+	Block      = iota // wait for space in the queue
+	DropNewest        // discard the message being logged
+	DropOldest        // discard the oldest queued message to make room
+	DropBelow         // discard the message being logged if it is less severe than the threshold, otherwise wait
+)
+
+// AsyncReceiver queues log messages and passes them to its Receiver from a background goroutine,
+// so that logging doesn't wait on slow writers. Callers are resolved when each message is queued, and passed on
+// to Receivers that implement EntryReceiver, such as Collector and Relay.
+// Settings changes are passed straight through to the Receiver, without waiting for queued messages.
+// AsyncReceiver implements the Receiver interface.
+type AsyncReceiver struct {
+	rcvr      Receiver
+	queue     chan *Entry
+	policy    int
+	threshold int
+	dropped   atomic.Uint64
+
+	mu     sync.RWMutex // held for reading while queueing, and for writing to close the queue
+	closed bool
+	done   chan struct{} // closed when the background goroutine exits
+
+	pendingMu sync.Mutex
+	drained   *sync.Cond // signalled when pending reaches zero
+	pending   int        // queued entries not yet logged or dropped
+}
+
+// NewAsyncReceiver creates a new AsyncReceiver which queues up to size messages for rcvr,
+// handling a full queue according to policy. With policy DropBelow, messages of lower priority than threshold
+// are dropped when the queue is full, so that e.g. a threshold of LAlert never drops LEmerg or LAlert messages.
+func NewAsyncReceiver(rcvr Receiver, size int, policy int, threshold int) *AsyncReceiver {
+	a := &AsyncReceiver{
+		rcvr:      rcvr,
+		queue:     make(chan *Entry, size),
+		policy:    policy,
+		threshold: threshold,
+		done:      make(chan struct{}),
+	}
+	a.drained = sync.NewCond(&a.pendingMu)
+	go a.run()
+	return a
+}
+
+// run logs queued entries until the queue is closed.
+func (a *AsyncReceiver) run() {
+	defer close(a.done)
+	for e := range a.queue {
+		logEntry(a.rcvr, e)
+		a.addPending(-1)
+	}
+}
+
+// enqueue queues e, applying the AsyncReceiver's policy if the queue is full.
+func (a *AsyncReceiver) enqueue(e *Entry) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	if a.closed {
+		a.dropped.Add(1)
+		return
+	}
+	a.addPending(1)
+	select {
+	case a.queue <- e:
+		return
+	default:
+	}
+	switch a.policy {
+	case DropNewest:
+		a.drop()
+		return
+	case DropBelow:
+		if e.Severity > a.threshold {
+			a.drop()
+			return
+		}
+	case DropOldest:
+		for {
+			select {
+			case a.queue <- e:
+				return
+			default:
+			}
+			select {
+			case <-a.queue:
+				a.drop()
+			default:
+			}
+		}
+	}
+	a.queue <- e
+}
+
+// drop records that a queued or queueing entry was discarded.
+func (a *AsyncReceiver) drop() {
+	a.dropped.Add(1)
+	a.addPending(-1)
+}
+
+// addPending adjusts the count of pending entries, signalling Flush when it reaches zero.
+func (a *AsyncReceiver) addPending(n int) {
+	a.pendingMu.Lock()
+	a.pending += n
+	if a.pending == 0 {
+		a.drained.Broadcast()
+	}
+	a.pendingMu.Unlock()
+}
+
+// log resolves the caller for msg and fields and queues the resulting Entry.
+func (a *AsyncReceiver) log(severity int, calldepth int, msg string, fields []Field) {
+	e := &Entry{Time: time.Now(), Severity: severity, Message: msg, Fields: fields}
+	e.File, e.Line = caller(calldepth, Llongfile)
+	a.enqueue(e)
+}
+
+// Dropped returns the number of messages the AsyncReceiver has discarded.
+func (a *AsyncReceiver) Dropped() uint64 {
+	return a.dropped.Load()
+}
+
+// Flush waits until the queue is empty and all queued messages have been passed to the Receiver,
+// then flushes the Receiver if it is a Flusher.
+func (a *AsyncReceiver) Flush() error {
+	a.pendingMu.Lock()
+	for a.pending > 0 {
+		a.drained.Wait()
+	}
+	a.pendingMu.Unlock()
+	return flush(a.rcvr)
+}
+
+// Close flushes the queue, stops the background goroutine and closes the Receiver if it is a Closer.
+// Messages logged after Close are dropped.
+func (a *AsyncReceiver) Close() error {
+	a.mu.Lock()
+	if !a.closed {
+		a.closed = true
+		close(a.queue)
+	}
+	a.mu.Unlock()
+	<-a.done
+	return closeReceiver(a.rcvr)
+}
+
+// SetOutput calls SetOutput on the AsyncReceiver's Receiver.
+func (a *AsyncReceiver) SetOutput(w io.Writer) { a.rcvr.SetOutput(w) }
+
+// SetFlags calls SetFlags on the AsyncReceiver's Receiver.
+func (a *AsyncReceiver) SetFlags(flag int, maskOp int) { a.rcvr.SetFlags(flag, maskOp) }
+
+// SetPrefix calls SetPrefix on the AsyncReceiver's Receiver.
+func (a *AsyncReceiver) SetPrefix(prefix string) { a.rcvr.SetPrefix(prefix) }
+
+// SetVerbosity calls SetVerbosity on the AsyncReceiver's Receiver.
+func (a *AsyncReceiver) SetVerbosity(verbosity int) { a.rcvr.SetVerbosity(verbosity) }
+
+// Output queues s as the message of an entry at severity Notice.
+func (a *AsyncReceiver) Output(calldepth int, s string) error {
+	a.log(LNotice, calldepth+1, s, nil)
+	return nil
+}
+
+// Log generates the message and queues it.
+func (a *AsyncReceiver) Log(severity int, calldepth int, v ...interface{}) {
+	a.log(severity, calldepth+1, fmt.Sprint(v...), nil)
+}
+
+// Logf generates the message and queues it.
+func (a *AsyncReceiver) Logf(severity int, calldepth int, format string, v ...interface{}) {
+	a.log(severity, calldepth+1, fmt.Sprintf(format, v...), nil)
+}
+
+// Logln generates the message and queues it.
+func (a *AsyncReceiver) Logln(severity int, calldepth int, v ...interface{}) {
+	a.log(severity, calldepth+1, fmt.Sprintln(v...), nil)
+}
+
+// Logw queues msg and fields.
+func (a *AsyncReceiver) Logw(severity int, calldepth int, msg string, fields []Field) {
+	a.log(severity, calldepth+1, msg, fields)
+}
+
+// LogEntry queues e.
+func (a *AsyncReceiver) LogEntry(e *Entry) error {
+	entry := *e
+	a.enqueue(&entry)
+	return nil
+}
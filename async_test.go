@@ -0,0 +1,115 @@
+package relog
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// blockingWriter blocks writes until its gate is closed, signalling entered when a write begins.
+type blockingWriter struct {
+	gate    chan struct{}
+	entered chan struct{}
+	mu      sync.Mutex
+	buf     bytes.Buffer
+}
+
+func (w *blockingWriter) Write(p []byte) (int, error) {
+	select {
+	case w.entered <- struct{}{}:
+	default:
+	}
+	<-w.gate
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.buf.Write(p)
+}
+
+func (w *blockingWriter) String() string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.buf.String()
+}
+
+var AsyncTests = []struct {
+	policy  int
+	dropped uint64
+	match   []string
+}{
+	{DropNewest, 3, []string{"[WARNING] 0", "[WARNING] 1", "[WARNING] 2"}},
+	{DropOldest, 3, []string{"[WARNING] 0", "[WARNING] 4", "[ALERT] 5"}},
+	{DropBelow, 2, []string{"[WARNING] 0", "[WARNING] 1", "[WARNING] 2", "[ALERT] 5"}},
+}
+
+func TestAsyncReceiver(t *testing.T) {
+	for _, test := range AsyncTests {
+		w := &blockingWriter{gate: make(chan struct{}), entered: make(chan struct{}, 1)}
+		async := NewAsyncReceiver(NewCollector(w, LDebug, "", Lshortfile), 2, test.policy, LAlert)
+		relay := New(LDebug, "", 0)
+		relay.AddReceiver(async)
+
+		relay.Warn(0)
+		<-w.entered // the background goroutine is now blocked writing the first message
+		logged := make(chan struct{})
+		go func() {
+			for i := 1; i < 5; i++ {
+				relay.Warn(i)
+			}
+			relay.Alert(5) // blocks until the gate is opened with DropBelow
+			close(logged)
+		}()
+		for async.Dropped() < test.dropped {
+			time.Sleep(time.Millisecond)
+		}
+		close(w.gate)
+		<-logged
+		async.Close()
+
+		if async.Dropped() != test.dropped {
+			t.Errorf("AsyncReceiver policy %d dropped wrong count EXP: %d GOT: %d", test.policy, test.dropped, async.Dropped())
+		}
+		lines := strings.Split(strings.TrimSpace(w.String()), "\n")
+		if len(lines) != len(test.match) {
+			t.Fatalf("AsyncReceiver policy %d logged wrong messages EXP: %q GOT: %q", test.policy, test.match, lines)
+		}
+		for i, line := range lines {
+			if !strings.HasPrefix(line, "async_test.go:") || !strings.HasSuffix(line, test.match[i]) {
+				t.Errorf("AsyncReceiver policy %d message didn't match\nEXP: async_test.go:NN: %s^\nGOT: %s^", test.policy, test.match[i], line)
+			}
+		}
+	}
+}
+
+func TestAsyncReceiverBlock(t *testing.T) {
+	w := &blockingWriter{gate: make(chan struct{}), entered: make(chan struct{}, 1)}
+	async := NewAsyncReceiver(NewCollector(w, LDebug, "", 0), 2, Block, 0)
+	relay := New(LDebug, "", 0)
+	relay.AddReceiver(async)
+
+	relay.Warn(0)
+	<-w.entered // the background goroutine is now blocked writing the first message
+	var returned atomic.Int32
+	logged := make(chan struct{})
+	go func() {
+		for i := 1; i < 4; i++ {
+			relay.Warn(i) // the third call waits for space in the full queue
+			returned.Add(1)
+		}
+		close(logged)
+	}()
+	time.Sleep(50 * time.Millisecond)
+	if n := returned.Load(); n != 2 {
+		t.Errorf("Logging calls returned while the queue was full EXP: %d GOT: %d", 2, n)
+	}
+	close(w.gate)
+	<-logged
+	async.Close()
+
+	exp := "[WARNING] 0\n[WARNING] 1\n[WARNING] 2\n[WARNING] 3\n"
+	if result := w.String(); result != exp || async.Dropped() != 0 {
+		t.Errorf("AsyncReceiver Block output didn't match\nEXP: %s^\nGOT: %s^ with %d dropped", exp, result, async.Dropped())
+	}
+}
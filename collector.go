@@ -1,92 +1,211 @@
 package relog
 
 import (
+	"bytes"
 	"fmt"
 	"io"
-	"log"
+	"sync"
+	"sync/atomic"
+	"time"
 )
 
-// Collector is a wrapper on the golang log package type Logger, with the addition
-// of a verbosity parameter to control what level of log messages should be sent to its Logger.
-// Collector implements the Receiver interface.
+// Collector writes the log messages it receives to an io.Writer, using its Formatter to lay out each Entry,
+// with a verbosity parameter to control what level of log messages should be written.
+// Collector implements the Receiver interface, and is safe for concurrent use.
 type Collector struct {
-	logger    *log.Logger
-	verbosity int
-	flag      int // stored at the Collector level to allow masking modifications
+	mu        sync.Mutex // serializes writes to w, and changes to the Collector's settings
+	w         io.Writer
+	formatter Formatter
+	verbosity atomic.Int32
+	flag      atomic.Int32
+	prefix    atomic.Value // string
+	stack     atomic.Int32 // the least severe severity at which stacks are captured
 }
 
+// bufferPool holds the buffers Collectors format entries into before writing them.
+var bufferPool = sync.Pool{New: func() interface{} { return new(bytes.Buffer) }}
+
 // NewCollector creates a new Collector using the provided io.Writer and settings.
-func NewCollector(w io.Writer, verbosity int, prefix string, flag int) *Collector {
-	return &Collector{
-		verbosity: verbosity,
-		flag:      flag,
-		logger:    log.New(w, prefix, flag),
+// Entries are laid out by the given Formatter, or by TextFormatter if none is given.
+func NewCollector(w io.Writer, verbosity int, prefix string, flag int, formatter ...Formatter) *Collector {
+	c := &Collector{
+		w:         w,
+		formatter: TextFormatter{},
+	}
+	c.verbosity.Store(int32(verbosity))
+	c.flag.Store(int32(flag))
+	c.prefix.Store(prefix)
+	c.stack.Store(NoStacks)
+	if len(formatter) > 0 && formatter[0] != nil {
+		c.formatter = formatter[0]
 	}
+	return c
 }
 
-// SetFlags sets the Collector's flag via a masking operation, and sets the logger's flag to the resultant value.
+// SetFlags sets the Collector's flag via a masking operation.
 func (c *Collector) SetFlags(flag int, maskOp int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	f := c.Flags()
 	switch maskOp {
 	case NONE:
-		c.flag = flag
+		f = flag
 	case AND:
-		c.flag = c.flag & flag
+		f = f & flag
 	case OR:
-		c.flag = c.flag | flag
+		f = f | flag
 	case XOR:
-		c.flag = c.flag ^ flag
+		f = f ^ flag
 	case ANDNOT:
-		c.flag = c.flag &^ flag
+		f = f &^ flag
 	}
-	c.logger.SetFlags(c.flag)
+	c.flag.Store(int32(f))
+}
+
+// Flags returns the Collector's output flags.
+func (c *Collector) Flags() int {
+	return int(c.flag.Load())
 }
 
-// SetPrefix sets the Collectors's logger's prefix.
+// SetPrefix sets the Collectors's prefix.
 func (c *Collector) SetPrefix(prefix string) {
-	c.logger.SetPrefix(prefix)
+	c.prefix.Store(prefix)
 }
 
-// Prefix returns the Collectors's logger's prefix.
+// Prefix returns the Collectors's prefix.
 func (c *Collector) Prefix() string {
-	return c.logger.Prefix()
+	prefix, _ := c.prefix.Load().(string)
+	return prefix
 }
 
+// SetOutput sets the io.Writer the Collector writes to.
 func (c *Collector) SetOutput(w io.Writer) {
-	c.logger.SetOutput(w)
+	c.mu.Lock()
+	c.w = w
+	c.mu.Unlock()
 }
 
-// Output prepends the severity label and calls the Collector's logger.
+// Flush flushes the Collector's io.Writer, if it buffers output and has a Flush method, such as a *bufio.Writer.
+func (c *Collector) Flush() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if f, ok := c.w.(interface{ Flush() error }); ok {
+		return f.Flush()
+	}
+	return nil
+}
+
+// SetFormatter sets the Formatter used to lay out the Collector's entries.
+func (c *Collector) SetFormatter(f Formatter) {
+	c.mu.Lock()
+	c.formatter = f
+	c.mu.Unlock()
+}
+
+// Output writes s as the message of an entry at severity Notice, regardless of the Collector's verbosity.
+// As with log.Logger's Output, TextFormatter writes s without a severity label.
 func (c *Collector) Output(calldepth int, s string) error {
-	return c.logger.Output(calldepth+1, s)
+	e := c.entry(LNotice, calldepth+1, s, nil)
+	e.output = true
+	return c.write(&e)
 }
 
 // SetVerbosity sets the Collector's verbosity. Messages of lower priority than the verbosity are not logged.
 func (c *Collector) SetVerbosity(verbosity int) {
-	c.verbosity = verbosity
+	c.verbosity.Store(int32(verbosity))
 }
 
 // Verbosity returns the Collector's verbosity.
 func (c *Collector) Verbosity() int {
-	return c.verbosity
+	return int(c.verbosity.Load())
+}
+
+// SetStackSeverity sets the Collector to capture the stack of the logging goroutine for entries at severity
+// or more severe, e.g. LError; NoStacks, the default, captures none. Stacks are captured for entries logged via
+// Log, Logf, Logln, Logw and Output; entries passed to LogEntry keep the stack they were given.
+func (c *Collector) SetStackSeverity(severity int) {
+	c.stack.Store(int32(severity))
+}
+
+// StackSeverity returns the least severe severity at which the Collector captures stacks.
+func (c *Collector) StackSeverity() int {
+	return int(c.stack.Load())
+}
+
+// output builds the Entry for msg and fields and writes it.
+func (c *Collector) output(severity int, calldepth int, msg string, fields []Field) error {
+	e := c.entry(severity, calldepth+1, msg, fields)
+	return c.write(&e)
 }
 
-// Log generates the log string and calls Output
+// entry builds the Entry for msg and fields, logged from the caller calldepth frames above its caller.
+func (c *Collector) entry(severity int, calldepth int, msg string, fields []Field) Entry {
+	e := Entry{
+		Time:     time.Now(),
+		Severity: severity,
+		Prefix:   c.Prefix(),
+		Message:  msg,
+		Fields:   fields,
+		Flag:     c.Flags(),
+	}
+	if e.Flag&(Lshortfile|Llongfile) != 0 {
+		e.File, e.Line = caller(calldepth, e.Flag)
+	}
+	if severity <= c.StackSeverity() {
+		e.Stack = stack(calldepth)
+	}
+	return e
+}
+
+// write formats e and writes it to the Collector's io.Writer.
+func (c *Collector) write(e *Entry) error {
+	b := bufferPool.Get().(*bytes.Buffer)
+	defer bufferPool.Put(b)
+	b.Reset()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.formatter.Format(b, e); err != nil {
+		return err
+	}
+	_, err := c.w.Write(b.Bytes())
+	return err
+}
+
+// LogEntry writes e, whose caller has already been resolved, applying the Collector's prefix and flags.
+func (c *Collector) LogEntry(e *Entry) error {
+	if c.Verbosity() < e.Severity {
+		return nil
+	}
+	entry := *e
+	entry.Prefix = c.Prefix()
+	entry.setFlag(c.Flags())
+	return c.write(&entry)
+}
+
+// Log generates the log string and writes it.
 func (c *Collector) Log(severity int, calldepth int, v ...interface{}) {
-	if c.verbosity >= severity {
-		c.Output(calldepth+1, "["+severities[severity]+"] "+fmt.Sprint(v...))
+	if c.Verbosity() >= severity {
+		c.output(severity, calldepth+1, fmt.Sprint(v...), nil)
 	}
 }
 
-// Logf generates the log string and calls Output.
+// Logf generates the log string and writes it.
 func (c *Collector) Logf(severity int, calldepth int, format string, v ...interface{}) {
-	if c.verbosity >= severity {
-		c.Output(calldepth+1, "["+severities[severity]+"] "+fmt.Sprintf(format, v...))
+	if c.Verbosity() >= severity {
+		c.output(severity, calldepth+1, fmt.Sprintf(format, v...), nil)
 	}
 }
 
-// Logln generates the log string and calls Output.
+// Logln generates the log string and writes it.
 func (c *Collector) Logln(severity int, calldepth int, v ...interface{}) {
-	if c.verbosity >= severity {
-		c.Output(calldepth+1, "["+severities[severity]+"] "+fmt.Sprintln(v...))
+	if c.Verbosity() >= severity {
+		c.output(severity, calldepth+1, fmt.Sprintln(v...), nil)
+	}
+}
+
+// Logw generates the log string, with fields attached to the Entry, and writes it.
+func (c *Collector) Logw(severity int, calldepth int, msg string, fields []Field) {
+	if c.Verbosity() >= severity {
+		c.output(severity, calldepth+1, msg, fields)
 	}
 }
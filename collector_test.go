@@ -57,3 +57,15 @@ func TestCollector(t *testing.T) {
 		}
 	}
 }
+
+func TestCollectorOutput(t *testing.T) {
+	var output, std bytes.Buffer
+	collector := NewCollector(&output, LError, "Prefix ", log.Lshortfile)
+	logger := log.New(&std, "Prefix ", log.Lshortfile)
+	for _, o := range []interface{ Output(int, string) error }{collector, logger} {
+		o.Output(1, "output") // from the same line, so that the callers match
+	}
+	if result, exp := output.String(), std.String(); result != exp {
+		t.Errorf("Collector Output didn't match\nEXP: %s^\nGOT: %s^", exp, result)
+	}
+}
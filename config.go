@@ -0,0 +1,489 @@
+package relog
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Config describes a tree of named relays and outputs, as read by LoadConfig from a JSON document such as:
+//
+//	{
+//		"relays": {
+//			"app": {"verbosity": "debug", "prefix": "app", "receivers": ["console", "errors"]}
+//		},
+//		"outputs": {
+//			"console": {"type": "stderr", "verbosity": "info", "flags": ["Ldate", "Ltime", "Lshortfile"]},
+//			"errors": {"type": "file", "path": "/var/log/app/errors.log", "verbosity": "error",
+//				"format": "json", "max_size": 10485760, "rotate": "daily", "max_backups": 7, "compress": true}
+//		}
+//	}
+//
+// Severities are given as names, e.g. "warning" or "warn", or numbers, and flags as lists of flag names,
+// strings such as "Ldate|Ltime", or numbers. Verbosities default to LInfo.
+type Config struct {
+	Relays  map[string]RelayConfig  `json:"relays"`
+	Outputs map[string]OutputConfig `json:"outputs"`
+}
+
+// RelayConfig describes a Relay. Its receivers are named outputs or other relays.
+// If flags are given, they are applied to the receivers as by Relay.SetFlags.
+type RelayConfig struct {
+	Verbosity interface{} `json:"verbosity"`
+	Prefix    string      `json:"prefix"`
+	Flags     interface{} `json:"flags"`
+	Receivers []string    `json:"receivers"`
+}
+
+// OutputConfig describes a Receiver which writes log messages. Type is one of:
+//
+//	stderr, stdout: a Collector writing to the standard error or output
+//	file: a Collector writing to a RotatingFile at Path, rotated per MaxSize, Rotate ("hourly" or "daily"),
+//		MaxBackups, MaxAge (a duration, e.g. "168h") and Compress
+//	network: a Collector writing to a connection to Address over Network, e.g. "tcp"
+//	syslog: a SyslogReceiver for the daemon at Address over Network (the local daemon if Network is empty),
+//		with the given Facility (a name such as "local0", or number), Tag and SyslogFormat ("rfc3164" or "rfc5424")
+//
+// Collectors lay out entries with the Format "text" (the default), "logfmt", "json" or "glog".
+type OutputConfig struct {
+	Type      string      `json:"type"`
+	Verbosity interface{} `json:"verbosity"`
+	Prefix    string      `json:"prefix"`
+	Flags     interface{} `json:"flags"`
+	Format    string      `json:"format"`
+
+	Path       string `json:"path"`
+	MaxSize    int64  `json:"max_size"`
+	Rotate     string `json:"rotate"`
+	MaxBackups int    `json:"max_backups"`
+	MaxAge     string `json:"max_age"`
+	Compress   bool   `json:"compress"`
+
+	Network      string      `json:"network"`
+	Address      string      `json:"address"`
+	Facility     interface{} `json:"facility"`
+	Tag          string      `json:"tag"`
+	SyslogFormat string      `json:"syslog_format"`
+}
+
+// Tree holds the relays and outputs built from a Config, by name.
+type Tree struct {
+	Relays  map[string]*Relay
+	Outputs map[string]Receiver
+	closers []io.Closer
+}
+
+// Relay returns the named relay, or nil if there is none.
+func (t *Tree) Relay(name string) *Relay { return t.Relays[name] }
+
+// Register registers the Tree's relays and outputs under their names in the config.
+func (t *Tree) Register() {
+	for name, r := range t.Relays {
+		Register(name, r)
+	}
+	for name, rcvr := range t.Outputs {
+		Register(name, rcvr)
+	}
+}
+
+// Close closes the files, connections and other resources opened for the Tree's outputs.
+func (t *Tree) Close() error {
+	var err error
+	for _, c := range t.closers {
+		if e := c.Close(); e != nil && err == nil {
+			err = e
+		}
+	}
+	t.closers = nil
+	return err
+}
+
+// configError returns an error describing a problem with the config element at path, e.g. "outputs.console.type".
+func configError(path string, format string, v ...interface{}) error {
+	return fmt.Errorf("relog: config %s: %s", path, fmt.Sprintf(format, v...))
+}
+
+// LoadConfig reads the JSON config file at path, and builds the relays and outputs it describes.
+// TOML and YAML are not supported, to avoid depending on packages outside the standard library;
+// such documents can be decoded into a Config by the caller and built with Config.Build.
+func LoadConfig(path string) (*Tree, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".toml", ".yaml", ".yml":
+		return nil, fmt.Errorf("relog: config %s: only JSON config files are supported", path)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	c, err := ParseConfig(data)
+	if err != nil {
+		return nil, err
+	}
+	return c.Build()
+}
+
+// ParseConfig parses a JSON config document. Unknown keys are rejected, with an error naming their path.
+func ParseConfig(data []byte) (*Config, error) {
+	d := json.NewDecoder(bytes.NewReader(data))
+	d.DisallowUnknownFields()
+	var c Config
+	if err := d.Decode(&c); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		var syntaxErr *json.SyntaxError
+		switch {
+		case errors.As(err, &typeErr):
+			return nil, configError(typeErr.Field, "expected %s, got %s", typeErr.Type, typeErr.Value)
+		case errors.As(err, &syntaxErr):
+			return nil, fmt.Errorf("relog: config: invalid JSON at offset %d: %v", syntaxErr.Offset, err)
+		}
+		if err := unknownKey("", data, reflect.TypeOf(c)); err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("relog: config: %v", err)
+	}
+	return &c, nil
+}
+
+// unknownKey returns an error naming the first key, in sorted order, of the JSON document data at path that has
+// no field in t, looking into the objects and arrays held by t's struct fields, maps and slices.
+func unknownKey(path string, data []byte, t reflect.Type) error {
+	switch t.Kind() {
+	case reflect.Struct, reflect.Map:
+		var obj map[string]json.RawMessage
+		if json.Unmarshal(data, &obj) != nil {
+			return nil
+		}
+		keys := make([]string, 0, len(obj))
+		for key := range obj {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			elemPath := key
+			if path != "" {
+				elemPath = path + "." + key
+			}
+			elem := t
+			if t.Kind() == reflect.Map {
+				elem = t.Elem()
+			} else if f, ok := jsonField(t, key); ok {
+				elem = f.Type
+			} else {
+				return configError(elemPath, "unknown field")
+			}
+			if err := unknownKey(elemPath, obj[key], elem); err != nil {
+				return err
+			}
+		}
+	case reflect.Slice:
+		var arr []json.RawMessage
+		if json.Unmarshal(data, &arr) != nil {
+			return nil
+		}
+		for i, elem := range arr {
+			if err := unknownKey(fmt.Sprintf("%s[%d]", path, i), elem, t.Elem()); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// jsonField returns the field of the struct type t that encoding/json decodes key into, matching case insensitively.
+func jsonField(t reflect.Type, key string) (reflect.StructField, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name := strings.Split(f.Tag.Get("json"), ",")[0]
+		if name == "" {
+			name = f.Name
+		}
+		if f.IsExported() && name != "-" && strings.EqualFold(name, key) {
+			return f, true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+// Build creates the relays and outputs the Config describes. If any part of the Config is invalid, or an output
+// can't be opened, Build closes anything it opened and returns an error naming the offending element.
+func (c *Config) Build() (*Tree, error) {
+	t := &Tree{Relays: make(map[string]*Relay), Outputs: make(map[string]Receiver)}
+	names := make([]string, 0, len(c.Outputs))
+	for name := range c.Outputs {
+		names = append(names, name)
+	}
+	sort.Strings(names) // report errors deterministically
+	for _, name := range names {
+		if _, ok := c.Relays[name]; ok {
+			t.Close()
+			return nil, configError("outputs."+name, "name is also used by a relay")
+		}
+		rcvr, err := t.buildOutput("outputs."+name, c.Outputs[name])
+		if err != nil {
+			t.Close()
+			return nil, err
+		}
+		t.Outputs[name] = rcvr
+	}
+	names = names[:0]
+	for name := range c.Relays {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if _, err := t.buildRelay(c, name, nil); err != nil {
+			t.Close()
+			return nil, err
+		}
+	}
+	return t, nil
+}
+
+// buildRelay builds the named relay and the relays it refers to, if not already built.
+// visiting holds the relays being built, to detect cycles.
+func (t *Tree) buildRelay(c *Config, name string, visiting []string) (*Relay, error) {
+	if r, ok := t.Relays[name]; ok {
+		return r, nil
+	}
+	path := "relays." + name
+	for _, v := range visiting {
+		if v == name {
+			return nil, configError(path, "receivers form a cycle: %s", strings.Join(append(visiting, name), " -> "))
+		}
+	}
+	rc := c.Relays[name]
+	verbosity, err := configSeverity(path+".verbosity", rc.Verbosity)
+	if err != nil {
+		return nil, err
+	}
+	r := New(verbosity, rc.Prefix, 0)
+	for i, rname := range rc.Receivers {
+		rpath := fmt.Sprintf("%s.receivers[%d]", path, i)
+		if rcvr, ok := t.Outputs[rname]; ok {
+			r.AddReceiver(rcvr)
+			continue
+		}
+		if _, ok := c.Relays[rname]; !ok {
+			return nil, configError(rpath, "unknown relay or output %q", rname)
+		}
+		child, err := t.buildRelay(c, rname, append(visiting, name))
+		if err != nil {
+			return nil, err
+		}
+		r.AddReceiver(child)
+	}
+	if rc.Flags != nil {
+		flag, err := configFlags(path+".flags", rc.Flags)
+		if err != nil {
+			return nil, err
+		}
+		r.SetFlags(flag, NONE)
+	}
+	t.Relays[name] = r
+	return r, nil
+}
+
+// buildOutput builds the Receiver described by oc.
+func (t *Tree) buildOutput(path string, oc OutputConfig) (Receiver, error) {
+	verbosity, err := configSeverity(path+".verbosity", oc.Verbosity)
+	if err != nil {
+		return nil, err
+	}
+	flag := LstdFlags
+	if oc.Flags != nil {
+		if flag, err = configFlags(path+".flags", oc.Flags); err != nil {
+			return nil, err
+		}
+	}
+	if oc.Type == "syslog" {
+		return t.buildSyslog(path, oc, verbosity)
+	}
+	formatter, err := configFormatter(path+".format", oc.Format)
+	if err != nil {
+		return nil, err
+	}
+	var w io.Writer
+	switch oc.Type {
+	case "stderr":
+		w = os.Stderr
+	case "stdout":
+		w = os.Stdout
+	case "file":
+		if oc.Path == "" {
+			return nil, configError(path+".path", "required for file outputs")
+		}
+		every := RotateNever
+		switch oc.Rotate {
+		case "", "never":
+		case "hourly":
+			every = RotateHourly
+		case "daily":
+			every = RotateDaily
+		default:
+			return nil, configError(path+".rotate", "unknown rotation period %q", oc.Rotate)
+		}
+		var maxAge time.Duration
+		if oc.MaxAge != "" {
+			if maxAge, err = time.ParseDuration(oc.MaxAge); err != nil {
+				return nil, configError(path+".max_age", "%v", err)
+			}
+		}
+		f, err := OpenRotatingFile(oc.Path, oc.MaxSize, every, oc.MaxBackups)
+		if err != nil {
+			return nil, configError(path+".path", "%v", err)
+		}
+		f.SetMaxAge(maxAge)
+		f.SetCompress(oc.Compress)
+		t.closers = append(t.closers, f)
+		w = f
+	case "network":
+		if oc.Network == "" || oc.Address == "" {
+			return nil, configError(path, "network and address are required for network outputs")
+		}
+		conn, err := net.Dial(oc.Network, oc.Address)
+		if err != nil {
+			return nil, configError(path+".address", "%v", err)
+		}
+		t.closers = append(t.closers, conn)
+		w = conn
+	case "":
+		return nil, configError(path+".type", "required")
+	default:
+		return nil, configError(path+".type", "unknown output type %q", oc.Type)
+	}
+	return NewCollector(w, verbosity, oc.Prefix, flag, formatter), nil
+}
+
+// buildSyslog builds the SyslogReceiver described by oc.
+func (t *Tree) buildSyslog(path string, oc OutputConfig, verbosity int) (Receiver, error) {
+	facility := FacilityUser
+	if oc.Facility != nil {
+		var err error
+		if facility, err = configFacility(path+".facility", oc.Facility); err != nil {
+			return nil, err
+		}
+	}
+	format := RFC3164
+	switch strings.ToLower(oc.SyslogFormat) {
+	case "", "rfc3164":
+	case "rfc5424":
+		format = RFC5424
+	default:
+		return nil, configError(path+".syslog_format", "unknown syslog format %q", oc.SyslogFormat)
+	}
+	s, err := NewSyslogReceiver(oc.Network, oc.Address, facility, verbosity, oc.Tag)
+	if err != nil {
+		return nil, configError(path, "%v", err)
+	}
+	s.SetFormat(format)
+	s.SetPrefix(oc.Prefix)
+	t.closers = append(t.closers, s)
+	return s, nil
+}
+
+// configSeverity converts a severity name or number from a config document, defaulting to LInfo.
+func configSeverity(path string, v interface{}) (int, error) {
+	switch v := v.(type) {
+	case nil:
+		return LInfo, nil
+	case float64:
+		if v == float64(int(v)) && definedSeverity(int(v)) {
+			return int(v), nil
+		}
+	case string:
+		if severity, err := ParseSeverity(v); err == nil {
+			return int(severity), nil
+		}
+	}
+	return 0, configError(path, "invalid severity %v", v)
+}
+
+// flagNames maps the names of the output flags to their values.
+var flagNames = map[string]int{
+	"Ldate": Ldate, "Ltime": Ltime, "Lmicroseconds": Lmicroseconds, "Llongfile": Llongfile,
+	"Lshortfile": Lshortfile, "LUTC": LUTC, "LstdFlags": LstdFlags,
+}
+
+// configFlags converts output flags from a config document: a number, a list of flag names,
+// or a string of flag names separated by "|".
+func configFlags(path string, v interface{}) (int, error) {
+	var names []string
+	switch v := v.(type) {
+	case float64:
+		if v == float64(int(v)) && v >= 0 {
+			return int(v), nil
+		}
+		return 0, configError(path, "invalid flags %v", v)
+	case string:
+		if v != "" {
+			names = strings.Split(v, "|")
+		}
+	case []interface{}:
+		for i, name := range v {
+			s, ok := name.(string)
+			if !ok {
+				return 0, configError(fmt.Sprintf("%s[%d]", path, i), "expected flag name, got %v", name)
+			}
+			names = append(names, s)
+		}
+	default:
+		return 0, configError(path, "invalid flags %v", v)
+	}
+	flag := 0
+	for _, name := range names {
+		f, ok := flagNames[strings.TrimSpace(name)]
+		if !ok {
+			return 0, configError(path, "unknown flag %q", name)
+		}
+		flag |= f
+	}
+	return flag, nil
+}
+
+// configFormatter returns the named Formatter.
+func configFormatter(path string, name string) (Formatter, error) {
+	switch strings.ToLower(name) {
+	case "", "text":
+		return TextFormatter{}, nil
+	case "logfmt":
+		return LogfmtFormatter{}, nil
+	case "json":
+		return JSONFormatter{}, nil
+	case "glog":
+		return GlogFormatter{}, nil
+	}
+	return nil, configError(path, "unknown format %q", name)
+}
+
+// facilityNames maps the names of syslog facilities to their values.
+var facilityNames = map[string]int{
+	"kern": FacilityKern, "user": FacilityUser, "mail": FacilityMail, "daemon": FacilityDaemon,
+	"auth": FacilityAuth, "syslog": FacilitySyslog, "lpr": FacilityLpr, "news": FacilityNews,
+	"uucp": FacilityUucp, "cron": FacilityCron, "authpriv": FacilityAuthpriv, "ftp": FacilityFtp,
+	"local0": FacilityLocal0, "local1": FacilityLocal1, "local2": FacilityLocal2, "local3": FacilityLocal3,
+	"local4": FacilityLocal4, "local5": FacilityLocal5, "local6": FacilityLocal6, "local7": FacilityLocal7,
+}
+
+// configFacility converts a syslog facility name or number from a config document.
+func configFacility(path string, v interface{}) (int, error) {
+	switch v := v.(type) {
+	case float64:
+		if v == float64(int(v)) {
+			return int(v), nil
+		}
+	case string:
+		if facility, ok := facilityNames[strings.ToLower(v)]; ok {
+			return facility, nil
+		}
+	}
+	return 0, configError(path, "invalid syslog facility %v", v)
+}
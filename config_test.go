@@ -0,0 +1,79 @@
+package relog
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadConfig(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "relog.json")
+	logPath := filepath.Join(dir, "app.log")
+	config := `{
+		"relays": {
+			"app": {"verbosity": "debug", "prefix": "app", "receivers": ["db", "file"]},
+			"db": {"verbosity": 4, "prefix": "db", "receivers": ["file"]}
+		},
+		"outputs": {
+			"file": {"type": "file", "path": "` + logPath + `", "verbosity": "info", "flags": ""}
+		}
+	}`
+	if err := os.WriteFile(path, []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+	tree, err := LoadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	app := tree.Relay("app")
+	app.Infof("started")
+	app.Warnf("slow")
+	app.Debug("hidden")
+	if err := tree.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := os.ReadFile(logPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp := "[INFO] app started\n[WARNING] db app slow\n[WARNING] app slow\n"
+	if result := string(b); result != exp {
+		t.Errorf("Config output didn't match\nEXP: %s^\nGOT: %s^", exp, result)
+	}
+}
+
+var ConfigErrorTests = []struct {
+	config string
+	exp    string
+}{
+	{`{"outputs": {"out": {"type": "pipe"}}}`, `relog: config outputs.out.type: unknown output type "pipe"`},
+	{`{"outputs": {"out": {"type": "stderr", "verbosity": "loud"}}}`, `relog: config outputs.out.verbosity: invalid severity loud`},
+	{`{"outputs": {"out": {"type": "stderr", "flags": ["Ldate", "Lnano"]}}}`, `relog: config outputs.out.flags: unknown flag "Lnano"`},
+	{`{"outputs": {"out": {"type": "stderr", "format": "xml"}}}`, `relog: config outputs.out.format: unknown format "xml"`},
+	{`{"outputs": {"out": {"type": "file"}}}`, `relog: config outputs.out.path: required for file outputs`},
+	{`{"outputs": {"out": {"type": "file", "path": "x.log", "max_backups": "7"}}}`, `relog: config outputs.out.max_backups: expected int, got string`},
+	{`{"relays": {"app": {"receivers": ["out"]}}}`, `relog: config relays.app.receivers[0]: unknown relay or output "out"`},
+	{`{"relays": {"a": {"receivers": ["b"]}, "b": {"receivers": ["a"]}}}`, `relog: config relays.a: receivers form a cycle: a -> b -> a`},
+	{`{"relays": {"app": {"flags": 1.5}}}`, `relog: config relays.app.flags: invalid flags 1.5`},
+	{`{"relay": {}}`, `relog: config relay: unknown field`},
+	{`{"relays": {"app": {"colour": true}}}`, `relog: config relays.app.colour: unknown field`},
+	{`{"outputs": {"out": {"type": "stderr", "Max_Size": 1, "level": "info"}}}`, `relog: config outputs.out.level: unknown field`},
+}
+
+func TestConfigErrors(t *testing.T) {
+	for _, test := range ConfigErrorTests {
+		var result string
+		c, err := ParseConfig([]byte(test.config))
+		if err == nil {
+			_, err = c.Build()
+		}
+		if err != nil {
+			result = err.Error()
+		}
+		if result != test.exp {
+			t.Errorf("Config error didn't match\nEXP: %s^\nGOT: %s^", test.exp, result)
+		}
+	}
+}
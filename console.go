@@ -0,0 +1,184 @@
+package relog
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// ANSI escape sequences used by ConsoleFormatter.
+const (
+	ansiReset = "\x1b[0m"
+	ansiFaint = "\x1b[2m"
+	ansiKey   = "\x1b[36m"
+)
+
+// consoleColors holds the ANSI color of each severity's label.
+var consoleColors = []string{
+	"\x1b[1;31m", // Emerg: bold red
+	"\x1b[1;31m", // Alert: bold red
+	"\x1b[31m",   // Critical: red
+	"\x1b[91m",   // Error: bright red
+	"\x1b[33m",   // Warn: yellow
+	"\x1b[36m",   // Notice: cyan
+	"\x1b[32m",   // Info: green
+	"\x1b[90m",   // Debug: grey
+}
+
+// consoleLabelWidth is the width of the longest severity label, "[EMERGENCY]", to which labels are padded.
+const consoleLabelWidth = len("[EMERGENCY]")
+
+// consoleCallerWidth is the width to which callers are padded, so that most messages line up.
+const consoleCallerWidth = 20
+
+// consoleMessageWidth is the width to which messages followed by fields are padded, so that most fields line up.
+const consoleMessageWidth = 40
+
+// consoleIndent indents the lines of multi-line field values, which follow the entry's first line.
+const consoleIndent = "    "
+
+// ConsoleFormatter lays out entries for reading in a terminal, with the severity labels, callers and messages
+// padded so that messages and fields line up, callers shortened to their file names, and the stack, if captured,
+// on the following lines:
+//
+//	2009/01/23 01:23:23 [ERROR]     d.go:23              prefix message                           key=value
+//
+// Field values are pretty-printed: maps, structs, slices and arrays as JSON, errors and Stringers by their text,
+// and values spanning several lines below the entry's first line, each under its key.
+// As with TextFormatter, entries written via Output have no severity label.
+//
+// If Color is set, labels are coloured by severity, from bold red for Emerg and Alert to grey for Debug,
+// callers are faint and field keys are highlighted.
+type ConsoleFormatter struct {
+	Color bool
+}
+
+// Format writes the Entry in console form.
+func (f ConsoleFormatter) Format(w io.Writer, e *Entry) error {
+	var b bytes.Buffer
+	if e.Flag&(Ldate|Ltime|Lmicroseconds) != 0 {
+		t := e.time()
+		if e.Flag&Ldate != 0 {
+			b.WriteString(t.Format("2006/01/02 "))
+		}
+		if e.Flag&Lmicroseconds != 0 {
+			b.WriteString(t.Format("15:04:05.000000 "))
+		} else if e.Flag&Ltime != 0 {
+			b.WriteString(t.Format("15:04:05 "))
+		}
+	}
+	label := ""
+	if !e.output {
+		label = "[" + severityLabel(e.Severity) + "]"
+		f.color(&b, consoleColors[syslogSeverity(e.Severity)], label)
+	}
+	pad := 1
+	if len(label) < consoleLabelWidth {
+		pad += consoleLabelWidth - len(label)
+	}
+	b.WriteString(strings.Repeat(" ", pad))
+	if e.File != "" {
+		caller := filepath.Base(e.File) + ":" + strconv.Itoa(e.Line)
+		f.color(&b, ansiFaint, caller)
+		pad := 1
+		if len(caller) < consoleCallerWidth {
+			pad += consoleCallerWidth - len(caller)
+		}
+		b.WriteString(strings.Repeat(" ", pad))
+	}
+	msg := e.Prefix + e.message()
+	b.WriteString(msg)
+	var blocks bytes.Buffer // multi-line field values, written after the first line
+	gap := 1                // spaces before the next field on the first line
+	if len(msg) < consoleMessageWidth {
+		gap += consoleMessageWidth - len(msg)
+	}
+	for _, field := range e.Fields {
+		s := consoleValue(field.Value)
+		if strings.Contains(strings.TrimSuffix(s, "\n"), "\n") {
+			blocks.WriteString(consoleIndent)
+			f.color(&blocks, ansiKey, field.Key)
+			blocks.WriteString(":\n")
+			for _, line := range strings.Split(strings.TrimSuffix(s, "\n"), "\n") {
+				blocks.WriteString(consoleIndent + consoleIndent + line + "\n")
+			}
+			continue
+		}
+		if s == "" || strings.ContainsAny(s, " \t\r\n\"=") {
+			s = strconv.Quote(s)
+		}
+		b.WriteString(strings.Repeat(" ", gap))
+		gap = 1
+		f.color(&b, ansiKey, field.Key)
+		b.WriteByte('=')
+		b.WriteString(s)
+	}
+	b.WriteByte('\n')
+	b.Write(blocks.Bytes())
+	if e.Stack != "" {
+		f.color(&b, ansiFaint, e.Stack)
+		b.WriteByte('\n')
+	}
+	_, err := w.Write(b.Bytes())
+	return err
+}
+
+// consoleValue returns the text of a field value for ConsoleFormatter: maps, structs, slices and arrays
+// as indented JSON, errors and Stringers by their text, and other values as by fmt.
+func consoleValue(v interface{}) string {
+	switch v := v.(type) {
+	case error:
+		return v.Error()
+	case fmt.Stringer:
+		return v.String()
+	case []byte:
+		return string(v)
+	}
+	switch reflect.Indirect(reflect.ValueOf(v)).Kind() {
+	case reflect.Map, reflect.Struct, reflect.Slice, reflect.Array:
+		if b, err := json.MarshalIndent(v, "", "  "); err == nil {
+			return string(b)
+		}
+	}
+	return fmt.Sprintf("%+v", v)
+}
+
+// color writes s to b, in the given color if the ConsoleFormatter uses color.
+func (f ConsoleFormatter) color(b *bytes.Buffer, color string, s string) {
+	if f.Color {
+		b.WriteString(color + s + ansiReset)
+	} else {
+		b.WriteString(s)
+	}
+}
+
+// NewConsoleCollector creates a new Collector for writing to a terminal. If w is a terminal, entries are laid out
+// by ConsoleFormatter, in color unless the NO_COLOR environment variable is set; otherwise, as when w is a file
+// or pipe, they are laid out by TextFormatter. Setting FORCE_COLOR to a value other than "0" forces colored
+// console output to any writer, overriding NO_COLOR.
+func NewConsoleCollector(w io.Writer, verbosity int, prefix string, flag int) *Collector {
+	return NewCollector(w, verbosity, prefix, flag, consoleFormatter(w))
+}
+
+// consoleFormatter returns the Formatter NewConsoleCollector uses for w.
+func consoleFormatter(w io.Writer) Formatter {
+	if force := os.Getenv("FORCE_COLOR"); force != "" && force != "0" {
+		return ConsoleFormatter{Color: true}
+	}
+	if !isTerminal(w) {
+		return TextFormatter{}
+	}
+	return ConsoleFormatter{Color: os.Getenv("NO_COLOR") == ""}
+}
+
+// isTerminal reports whether w is a terminal.
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	return ok && isatty(f)
+}
@@ -0,0 +1,16 @@
+//go:build darwin || dragonfly || freebsd || netbsd || openbsd
+
+package relog
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// isatty reports whether f is a terminal, by asking for its terminal attributes.
+func isatty(f *os.File) bool {
+	var termios syscall.Termios
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, f.Fd(), syscall.TIOCGETA, uintptr(unsafe.Pointer(&termios)))
+	return errno == 0
+}
@@ -0,0 +1,66 @@
+package relog
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"testing"
+)
+
+func TestConsoleCollector(t *testing.T) {
+	var output bytes.Buffer
+	t.Setenv("FORCE_COLOR", "")
+	if c := NewConsoleCollector(&output, LDebug, "", 0); c.formatter != (TextFormatter{}) {
+		t.Errorf("Console formatter for a buffer didn't match\nEXP: %T^\nGOT: %T^", TextFormatter{}, c.formatter)
+	}
+	t.Setenv("FORCE_COLOR", "1")
+	t.Setenv("NO_COLOR", "1")
+	c := NewConsoleCollector(&output, LDebug, "", 0)
+	c.Log(LWarn, 1, "forced")
+	exp := "\x1b[33m[WARNING]\x1b[0m   forced\n"
+	if result := output.String(); result != exp {
+		t.Errorf("Forced console output didn't match\nEXP: %q^\nGOT: %q^", exp, result)
+	}
+}
+
+func TestConsoleFormatterFields(t *testing.T) {
+	var output bytes.Buffer
+	e := Entry{
+		Severity: LInfo,
+		Message:  "request",
+		Fields: []Field{
+			{"err", errors.New("timed out")},
+			{"user", struct {
+				Name string `json:"name"`
+			}{"ann"}},
+			{"n", 3},
+		},
+	}
+	if err := (ConsoleFormatter{}).Format(&output, &e); err != nil {
+		t.Fatal(err)
+	}
+	exp := "[INFO]      request                                  err=\"timed out\" n=3\n" +
+		"    user:\n        {\n          \"name\": \"ann\"\n        }\n"
+	if result := output.String(); result != exp {
+		t.Errorf("Console fields didn't match\nEXP: %q^\nGOT: %q^", exp, result)
+	}
+
+	output.Reset()
+	c := NewCollector(&output, LDebug, "", 0, ConsoleFormatter{})
+	c.Output(1, "unlabelled")
+	exp = "            unlabelled\n"
+	if result := output.String(); result != exp {
+		t.Errorf("Console Output didn't match\nEXP: %q^\nGOT: %q^", exp, result)
+	}
+}
+
+func TestIsTerminal(t *testing.T) {
+	f, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if err != nil {
+		t.Skip(err)
+	}
+	defer f.Close()
+	if isTerminal(f) {
+		t.Errorf("%s reported as a terminal", os.DevNull)
+	}
+}
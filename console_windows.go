@@ -0,0 +1,12 @@
+package relog
+
+import (
+	"os"
+	"syscall"
+)
+
+// isatty reports whether f is a console, by asking for its console mode.
+func isatty(f *os.File) bool {
+	var mode uint32
+	return syscall.GetConsoleMode(syscall.Handle(f.Fd()), &mode) == nil
+}
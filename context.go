@@ -0,0 +1,298 @@
+package relog
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// ContextExtractor returns fields to attach to entries logged with a context, e.g. trace or request IDs
+// stored in the context by other packages.
+type ContextExtractor func(ctx context.Context) []Field
+
+type contextKey int
+
+const (
+	relayKey contextKey = iota
+	fieldsKey
+)
+
+var (
+	extractorsMu sync.Mutex
+	extractors   atomic.Value // []*extractor
+)
+
+// extractor wraps a registered ContextExtractor, so that it can be found again for removal.
+type extractor struct{ e ContextExtractor }
+
+// AddContextExtractor registers e to be called for every entry logged with a context, the fields it returns
+// being attached to the entry after any fields stored in the context with ContextWith.
+// It returns a function which removes e again.
+func AddContextExtractor(e ContextExtractor) (remove func()) {
+	extractorsMu.Lock()
+	defer extractorsMu.Unlock()
+	x := &extractor{e}
+	current, _ := extractors.Load().([]*extractor)
+	extractors.Store(append(current[:len(current):len(current)], x))
+	return func() {
+		extractorsMu.Lock()
+		defer extractorsMu.Unlock()
+		current, _ := extractors.Load().([]*extractor)
+		kept := make([]*extractor, 0, len(current))
+		for _, c := range current {
+			if c != x {
+				kept = append(kept, c)
+			}
+		}
+		extractors.Store(kept)
+	}
+}
+
+// NewContext returns a copy of ctx carrying r, for retrieval with FromContext.
+func NewContext(ctx context.Context, r *Relay) context.Context {
+	return context.WithValue(ctx, relayKey, r)
+}
+
+// FromContext returns the Relay stored in ctx by NewContext, or the standard Relay if there is none or ctx is nil.
+// The package level *Ctx functions log via the Relay returned by FromContext.
+func FromContext(ctx context.Context) *Relay {
+	if ctx == nil {
+		return std
+	}
+	if r, ok := ctx.Value(relayKey).(*Relay); ok {
+		return r
+	}
+	return std
+}
+
+// ContextWith returns a copy of ctx carrying the given alternating keys and values as fields, after any fields
+// already in ctx, to be attached to every entry logged with the context.
+func ContextWith(ctx context.Context, kv ...interface{}) context.Context {
+	fields, _ := ctx.Value(fieldsKey).([]Field)
+	return context.WithValue(ctx, fieldsKey, joinFields(fields, makeFields(kv)))
+}
+
+// contextFields returns the fields stored in ctx followed by those returned by the registered ContextExtractors.
+func contextFields(ctx context.Context) []Field {
+	if ctx == nil {
+		return nil
+	}
+	fields, _ := ctx.Value(fieldsKey).([]Field)
+	current, _ := extractors.Load().([]*extractor)
+	for _, x := range current {
+		fields = joinFields(fields, x.e(ctx))
+	}
+	return fields
+}
+
+// LogCtx calls Log, attaching the fields carried by ctx, if any.
+func (r *Relay) LogCtx(ctx context.Context, severity int, calldepth int, v ...interface{}) {
+	if !r.settings().enabled(severity, calldepth) {
+		return
+	}
+	if fields := contextFields(ctx); len(fields) > 0 {
+		r.Logw(severity, calldepth+1, fmt.Sprint(v...), fields)
+	} else {
+		r.Log(severity, calldepth+1, v...)
+	}
+}
+
+// LogfCtx calls Logf, attaching the fields carried by ctx, if any.
+func (r *Relay) LogfCtx(ctx context.Context, severity int, calldepth int, format string, v ...interface{}) {
+	if !r.settings().enabled(severity, calldepth) {
+		return
+	}
+	if fields := contextFields(ctx); len(fields) > 0 {
+		r.logwf(severity, calldepth+1, format, fmt.Sprintf(format, v...), fields)
+	} else {
+		r.Logf(severity, calldepth+1, format, v...)
+	}
+}
+
+// LoglnCtx calls Logln, attaching the fields carried by ctx, if any.
+func (r *Relay) LoglnCtx(ctx context.Context, severity int, calldepth int, v ...interface{}) {
+	if !r.settings().enabled(severity, calldepth) {
+		return
+	}
+	if fields := contextFields(ctx); len(fields) > 0 {
+		r.Logw(severity, calldepth+1, strings.TrimSuffix(fmt.Sprintln(v...), "\n"), fields)
+	} else {
+		r.Logln(severity, calldepth+1, v...)
+	}
+}
+
+// EmergCtx calls LogCtx with severity Emerg.
+func EmergCtx(ctx context.Context, v ...interface{}) { FromContext(ctx).LogCtx(ctx, LEmerg, 2, v...) }
+func (r *Relay) EmergCtx(ctx context.Context, v ...interface{}) {
+	r.LogCtx(ctx, LEmerg, r.calldepth, v...)
+}
+
+// EmergfCtx calls LogfCtx with severity Emerg.
+func EmergfCtx(ctx context.Context, format string, v ...interface{}) {
+	FromContext(ctx).LogfCtx(ctx, LEmerg, 2, format, v...)
+}
+func (r *Relay) EmergfCtx(ctx context.Context, format string, v ...interface{}) {
+	r.LogfCtx(ctx, LEmerg, r.calldepth, format, v...)
+}
+
+// EmerglnCtx calls LoglnCtx with severity Emerg.
+func EmerglnCtx(ctx context.Context, v ...interface{}) {
+	FromContext(ctx).LoglnCtx(ctx, LEmerg, 2, v...)
+}
+func (r *Relay) EmerglnCtx(ctx context.Context, v ...interface{}) {
+	r.LoglnCtx(ctx, LEmerg, r.calldepth, v...)
+}
+
+// AlertCtx calls LogCtx with severity Alert.
+func AlertCtx(ctx context.Context, v ...interface{}) { FromContext(ctx).LogCtx(ctx, LAlert, 2, v...) }
+func (r *Relay) AlertCtx(ctx context.Context, v ...interface{}) {
+	r.LogCtx(ctx, LAlert, r.calldepth, v...)
+}
+
+// AlertfCtx calls LogfCtx with severity Alert.
+func AlertfCtx(ctx context.Context, format string, v ...interface{}) {
+	FromContext(ctx).LogfCtx(ctx, LAlert, 2, format, v...)
+}
+func (r *Relay) AlertfCtx(ctx context.Context, format string, v ...interface{}) {
+	r.LogfCtx(ctx, LAlert, r.calldepth, format, v...)
+}
+
+// AlertlnCtx calls LoglnCtx with severity Alert.
+func AlertlnCtx(ctx context.Context, v ...interface{}) {
+	FromContext(ctx).LoglnCtx(ctx, LAlert, 2, v...)
+}
+func (r *Relay) AlertlnCtx(ctx context.Context, v ...interface{}) {
+	r.LoglnCtx(ctx, LAlert, r.calldepth, v...)
+}
+
+// CriticalCtx calls LogCtx with severity Critical.
+func CriticalCtx(ctx context.Context, v ...interface{}) {
+	FromContext(ctx).LogCtx(ctx, LCritical, 2, v...)
+}
+func (r *Relay) CriticalCtx(ctx context.Context, v ...interface{}) {
+	r.LogCtx(ctx, LCritical, r.calldepth, v...)
+}
+
+// CriticalfCtx calls LogfCtx with severity Critical.
+func CriticalfCtx(ctx context.Context, format string, v ...interface{}) {
+	FromContext(ctx).LogfCtx(ctx, LCritical, 2, format, v...)
+}
+func (r *Relay) CriticalfCtx(ctx context.Context, format string, v ...interface{}) {
+	r.LogfCtx(ctx, LCritical, r.calldepth, format, v...)
+}
+
+// CriticallnCtx calls LoglnCtx with severity Critical.
+func CriticallnCtx(ctx context.Context, v ...interface{}) {
+	FromContext(ctx).LoglnCtx(ctx, LCritical, 2, v...)
+}
+func (r *Relay) CriticallnCtx(ctx context.Context, v ...interface{}) {
+	r.LoglnCtx(ctx, LCritical, r.calldepth, v...)
+}
+
+// ErrorCtx calls LogCtx with severity Error.
+func ErrorCtx(ctx context.Context, v ...interface{}) { FromContext(ctx).LogCtx(ctx, LError, 2, v...) }
+func (r *Relay) ErrorCtx(ctx context.Context, v ...interface{}) {
+	r.LogCtx(ctx, LError, r.calldepth, v...)
+}
+
+// ErrorfCtx calls LogfCtx with severity Error.
+func ErrorfCtx(ctx context.Context, format string, v ...interface{}) {
+	FromContext(ctx).LogfCtx(ctx, LError, 2, format, v...)
+}
+func (r *Relay) ErrorfCtx(ctx context.Context, format string, v ...interface{}) {
+	r.LogfCtx(ctx, LError, r.calldepth, format, v...)
+}
+
+// ErrorlnCtx calls LoglnCtx with severity Error.
+func ErrorlnCtx(ctx context.Context, v ...interface{}) {
+	FromContext(ctx).LoglnCtx(ctx, LError, 2, v...)
+}
+func (r *Relay) ErrorlnCtx(ctx context.Context, v ...interface{}) {
+	r.LoglnCtx(ctx, LError, r.calldepth, v...)
+}
+
+// WarnCtx calls LogCtx with severity Warn.
+func WarnCtx(ctx context.Context, v ...interface{}) { FromContext(ctx).LogCtx(ctx, LWarn, 2, v...) }
+func (r *Relay) WarnCtx(ctx context.Context, v ...interface{}) {
+	r.LogCtx(ctx, LWarn, r.calldepth, v...)
+}
+
+// WarnfCtx calls LogfCtx with severity Warn.
+func WarnfCtx(ctx context.Context, format string, v ...interface{}) {
+	FromContext(ctx).LogfCtx(ctx, LWarn, 2, format, v...)
+}
+func (r *Relay) WarnfCtx(ctx context.Context, format string, v ...interface{}) {
+	r.LogfCtx(ctx, LWarn, r.calldepth, format, v...)
+}
+
+// WarnlnCtx calls LoglnCtx with severity Warn.
+func WarnlnCtx(ctx context.Context, v ...interface{}) { FromContext(ctx).LoglnCtx(ctx, LWarn, 2, v...) }
+func (r *Relay) WarnlnCtx(ctx context.Context, v ...interface{}) {
+	r.LoglnCtx(ctx, LWarn, r.calldepth, v...)
+}
+
+// NoticeCtx calls LogCtx with severity Notice.
+func NoticeCtx(ctx context.Context, v ...interface{}) { FromContext(ctx).LogCtx(ctx, LNotice, 2, v...) }
+func (r *Relay) NoticeCtx(ctx context.Context, v ...interface{}) {
+	r.LogCtx(ctx, LNotice, r.calldepth, v...)
+}
+
+// NoticefCtx calls LogfCtx with severity Notice.
+func NoticefCtx(ctx context.Context, format string, v ...interface{}) {
+	FromContext(ctx).LogfCtx(ctx, LNotice, 2, format, v...)
+}
+func (r *Relay) NoticefCtx(ctx context.Context, format string, v ...interface{}) {
+	r.LogfCtx(ctx, LNotice, r.calldepth, format, v...)
+}
+
+// NoticelnCtx calls LoglnCtx with severity Notice.
+func NoticelnCtx(ctx context.Context, v ...interface{}) {
+	FromContext(ctx).LoglnCtx(ctx, LNotice, 2, v...)
+}
+func (r *Relay) NoticelnCtx(ctx context.Context, v ...interface{}) {
+	r.LoglnCtx(ctx, LNotice, r.calldepth, v...)
+}
+
+// InfoCtx calls LogCtx with severity Info.
+func InfoCtx(ctx context.Context, v ...interface{}) { FromContext(ctx).LogCtx(ctx, LInfo, 2, v...) }
+func (r *Relay) InfoCtx(ctx context.Context, v ...interface{}) {
+	r.LogCtx(ctx, LInfo, r.calldepth, v...)
+}
+
+// InfofCtx calls LogfCtx with severity Info.
+func InfofCtx(ctx context.Context, format string, v ...interface{}) {
+	FromContext(ctx).LogfCtx(ctx, LInfo, 2, format, v...)
+}
+func (r *Relay) InfofCtx(ctx context.Context, format string, v ...interface{}) {
+	r.LogfCtx(ctx, LInfo, r.calldepth, format, v...)
+}
+
+// InfolnCtx calls LoglnCtx with severity Info.
+func InfolnCtx(ctx context.Context, v ...interface{}) { FromContext(ctx).LoglnCtx(ctx, LInfo, 2, v...) }
+func (r *Relay) InfolnCtx(ctx context.Context, v ...interface{}) {
+	r.LoglnCtx(ctx, LInfo, r.calldepth, v...)
+}
+
+// DebugCtx calls LogCtx with severity Debug.
+func DebugCtx(ctx context.Context, v ...interface{}) { FromContext(ctx).LogCtx(ctx, LDebug, 2, v...) }
+func (r *Relay) DebugCtx(ctx context.Context, v ...interface{}) {
+	r.LogCtx(ctx, LDebug, r.calldepth, v...)
+}
+
+// DebugfCtx calls LogfCtx with severity Debug.
+func DebugfCtx(ctx context.Context, format string, v ...interface{}) {
+	FromContext(ctx).LogfCtx(ctx, LDebug, 2, format, v...)
+}
+func (r *Relay) DebugfCtx(ctx context.Context, format string, v ...interface{}) {
+	r.LogfCtx(ctx, LDebug, r.calldepth, format, v...)
+}
+
+// DebuglnCtx calls LoglnCtx with severity Debug.
+func DebuglnCtx(ctx context.Context, v ...interface{}) {
+	FromContext(ctx).LoglnCtx(ctx, LDebug, 2, v...)
+}
+func (r *Relay) DebuglnCtx(ctx context.Context, v ...interface{}) {
+	r.LoglnCtx(ctx, LDebug, r.calldepth, v...)
+}
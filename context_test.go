@@ -0,0 +1,67 @@
+package relog
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+type traceKey struct{}
+
+func TestContextLogging(t *testing.T) {
+	var output bytes.Buffer
+	relay := New(LInfo, "", 0)
+	relay.AddWriter(&output, LDebug, "", Lshortfile)
+	remove := AddContextExtractor(func(ctx context.Context) []Field {
+		if id, ok := ctx.Value(traceKey{}).(string); ok {
+			return []Field{{"trace", id}}
+		}
+		return nil
+	})
+	t.Cleanup(remove)
+
+	ctx := context.WithValue(context.Background(), traceKey{}, "t1")
+	ctx = ContextWith(ctx, "request", 7)
+	ctx = NewContext(ctx, relay.With("component", "db"))
+
+	WarnfCtx(ctx, "context %d", 1)
+	result := output.String()
+	if exp := "[WARNING] context 1 component=db request=7 trace=t1\n"; !strings.HasSuffix(result, exp) {
+		t.Errorf("Context Warnf messages didn't match\nEXP: %s^\nGOT: %s^", exp, result)
+	} else if !strings.HasPrefix(result, "context_test.go:") {
+		t.Errorf("Context Warnf messages have wrong caller\nGOT: %s^", result)
+	}
+
+	output.Reset()
+	relay.DebugCtx(ctx, "dropped")
+	relay.InfoCtx(context.Background(), "context")
+	result = output.String()
+	if exp := "[INFO] context\n"; !strings.HasSuffix(result, exp) {
+		t.Errorf("Context Info messages didn't match\nEXP: %s^\nGOT: %s^", exp, result)
+	} else if !strings.HasPrefix(result, "context_test.go:") {
+		t.Errorf("Context Info messages have wrong caller\nGOT: %s^", result)
+	}
+}
+
+func TestFromContextStd(t *testing.T) {
+	var output bytes.Buffer
+	SetOutput(&output)
+	defer SetOutput(os.Stderr)
+	defer func(verbosity, flag int) { SetVerbosity(verbosity); SetFlags(flag) }(Verbosity(), Flags())
+	SetVerbosity(LDebug)
+	SetFlags(Lshortfile)
+
+	if FromContext(context.Background()) != std || FromContext(nil) != std {
+		t.Errorf("FromContext didn't return the standard Relay")
+	}
+	FromContext(context.Background()).Info("std")
+	InfoCtx(context.Background(), "ctx")
+	result := output.String()
+	exp := `^context_test\.go:\d+: \[INFO\] std\ncontext_test\.go:\d+: \[INFO\] ctx\n$`
+	if !regexp.MustCompile(exp).MatchString(result) {
+		t.Errorf("FromContext messages didn't match\nEXP: %s^\nGOT: %s^", exp, result)
+	}
+}
@@ -0,0 +1,193 @@
+package relog
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// numberPattern matches the numeric tokens replaced by IgnoreNumbers.
+var numberPattern = regexp.MustCompile(`[0-9]+`)
+
+// IgnoreNumbers is a DedupReceiver key function which treats messages differing only in their numbers as identical.
+func IgnoreNumbers(msg string) string {
+	return numberPattern.ReplaceAllString(msg, "#")
+}
+
+// DedupReceiver collapses repeated log messages, in the manner of syslogd: the first of a run of messages
+// with the same severity, prefix and message is passed to its Receiver, and the rest are counted, then reported as
+// "last message repeated N times" when a different message arrives, when the window since the first
+// message has elapsed (if a window is set), or on Flush. Fields are not compared. A Relay's prefix is part of the
+// messages it forwards, so the key function is applied to it too; the Prefix of entries passed to LogEntry is
+// compared apart from their message.
+// DedupReceiver implements the Receiver interface.
+type DedupReceiver struct {
+	rcvr      Receiver
+	now       func() time.Time
+	afterFunc func(d time.Duration, f func()) *time.Timer
+
+	mu       sync.Mutex
+	keyFunc  func(msg string) string
+	window   time.Duration
+	last     string    // key of the last message passed on, including its severity and prefix
+	severity int       // severity of the last message passed on
+	since    time.Time // when the last message was passed on
+	repeats  int       // messages matching last that have been suppressed
+	timer    *time.Timer
+}
+
+// NewDedupReceiver creates a new DedupReceiver which passes messages to rcvr. If window is zero, runs of
+// consecutive identical messages are collapsed however long they last; otherwise identical messages are
+// collapsed for at most window after the first, after which the count is reported and the next passes through.
+func NewDedupReceiver(rcvr Receiver, window time.Duration) *DedupReceiver {
+	return &DedupReceiver{rcvr: rcvr, now: time.Now, afterFunc: time.AfterFunc, window: window}
+}
+
+// SetKeyFunc sets the function mapping messages to the keys compared to detect repeats, e.g. IgnoreNumbers.
+// A nil function compares messages exactly.
+func (d *DedupReceiver) SetKeyFunc(f func(msg string) string) {
+	d.mu.Lock()
+	d.keyFunc = f
+	d.mu.Unlock()
+}
+
+// allow reports whether a message should be passed on, reporting any suppressed repeats of the previous message first.
+// The key function is applied to msg alone, so that entries with different prefixes are never collapsed.
+func (d *DedupReceiver) allow(severity int, prefix string, msg string) bool {
+	msg = strings.TrimSuffix(msg, "\n")
+	d.mu.Lock()
+	if d.keyFunc != nil {
+		msg = d.keyFunc(msg)
+	}
+	key := strconv.Itoa(severity) + ":" + strconv.Quote(prefix) + ":" + msg
+	now := d.now()
+	if key == d.last && (d.window <= 0 || now.Sub(d.since) < d.window) {
+		d.repeats++
+		if d.repeats == 1 && d.window > 0 {
+			d.timer = d.afterFunc(d.window-now.Sub(d.since), d.expire(key))
+		}
+		d.mu.Unlock()
+		return false
+	}
+	summary := d.reset()
+	d.last, d.severity, d.since = key, severity, now
+	d.mu.Unlock()
+	if summary != nil {
+		logEntry(d.rcvr, summary)
+	}
+	return true
+}
+
+// expire returns a function which reports the repeats of key, and lets the next message with key pass,
+// if key is still the last message when the window elapses.
+func (d *DedupReceiver) expire(key string) func() {
+	return func() {
+		d.mu.Lock()
+		var summary *Entry
+		if d.last == key {
+			summary = d.reset()
+			d.last = ""
+		}
+		d.mu.Unlock()
+		if summary != nil {
+			logEntry(d.rcvr, summary)
+		}
+	}
+}
+
+// reset clears the count of repeats, returning the entry reporting them, if any. The caller must hold d.mu.
+func (d *DedupReceiver) reset() *Entry {
+	if d.timer != nil {
+		d.timer.Stop()
+		d.timer = nil
+	}
+	if d.repeats == 0 {
+		return nil
+	}
+	e := &Entry{Time: d.now(), Severity: d.severity, Message: fmt.Sprintf("last message repeated %d times", d.repeats)}
+	d.repeats = 0
+	return e
+}
+
+// Flush reports any suppressed repeats of the last message, then flushes the Receiver if it is a Flusher.
+func (d *DedupReceiver) Flush() error {
+	d.mu.Lock()
+	summary := d.reset()
+	d.last = ""
+	d.mu.Unlock()
+	if summary != nil {
+		logEntry(d.rcvr, summary)
+	}
+	return flush(d.rcvr)
+}
+
+// Close reports any suppressed repeats of the last message, then closes the Receiver if it is a Closer.
+func (d *DedupReceiver) Close() error {
+	d.Flush()
+	return closeReceiver(d.rcvr)
+}
+
+// SetOutput calls SetOutput on the DedupReceiver's Receiver.
+func (d *DedupReceiver) SetOutput(w io.Writer) { d.rcvr.SetOutput(w) }
+
+// SetFlags calls SetFlags on the DedupReceiver's Receiver.
+func (d *DedupReceiver) SetFlags(flag int, maskOp int) { d.rcvr.SetFlags(flag, maskOp) }
+
+// SetPrefix calls SetPrefix on the DedupReceiver's Receiver.
+func (d *DedupReceiver) SetPrefix(prefix string) { d.rcvr.SetPrefix(prefix) }
+
+// SetVerbosity calls SetVerbosity on the DedupReceiver's Receiver.
+func (d *DedupReceiver) SetVerbosity(verbosity int) { d.rcvr.SetVerbosity(verbosity) }
+
+// Output passes s to the Receiver's Output unless it repeats the last message, at severity Notice.
+func (d *DedupReceiver) Output(calldepth int, s string) error {
+	if !d.allow(LNotice, "", s) {
+		return nil
+	}
+	return d.rcvr.Output(calldepth+1, s)
+}
+
+// Log passes the message to the Receiver unless it repeats the last message.
+func (d *DedupReceiver) Log(severity int, calldepth int, v ...interface{}) {
+	if d.allow(severity, "", fmt.Sprint(v...)) {
+		d.rcvr.Log(severity, calldepth+1, v...)
+	}
+}
+
+// Logf passes the message to the Receiver unless it repeats the last message.
+func (d *DedupReceiver) Logf(severity int, calldepth int, format string, v ...interface{}) {
+	if d.allow(severity, "", fmt.Sprintf(format, v...)) {
+		d.rcvr.Logf(severity, calldepth+1, format, v...)
+	}
+}
+
+// Logln passes the message to the Receiver unless it repeats the last message.
+func (d *DedupReceiver) Logln(severity int, calldepth int, v ...interface{}) {
+	if d.allow(severity, "", fmt.Sprintln(v...)) {
+		d.rcvr.Logln(severity, calldepth+1, v...)
+	}
+}
+
+// Logw passes msg and fields to the Receiver unless msg repeats the last message.
+func (d *DedupReceiver) Logw(severity int, calldepth int, msg string, fields []Field) {
+	if !d.allow(severity, "", msg) {
+		return
+	}
+	if fr, ok := d.rcvr.(FieldReceiver); ok {
+		fr.Logw(severity, calldepth+1, msg, fields)
+	} else {
+		d.rcvr.Log(severity, calldepth+1, msg+formatFields(fields))
+	}
+}
+
+// LogEntry passes e to the Receiver unless its prefix and message repeat the last message.
+func (d *DedupReceiver) LogEntry(e *Entry) error {
+	if !d.allow(e.Severity, e.Prefix, e.Message) {
+		return nil
+	}
+	return logEntry(d.rcvr, e)
+}
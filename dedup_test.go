@@ -0,0 +1,121 @@
+package relog
+
+import (
+	"bytes"
+	"regexp"
+	"testing"
+	"time"
+)
+
+func TestDedupReceiver(t *testing.T) {
+	var output bytes.Buffer
+	dedup := NewDedupReceiver(NewCollector(&output, LDebug, "", Lshortfile), 0)
+	dedup.SetKeyFunc(IgnoreNumbers)
+	relay := New(LDebug, "", 0)
+	relay.AddReceiver(dedup)
+
+	for i := 0; i < 4; i++ {
+		relay.Warnf("dedup %d", i)
+	}
+	relay.Errorf("dedup %d", 4)
+	relay.Errorln("dedup", 5)
+	relay.Info("dedup")
+	dedup.Flush()
+
+	exp := regexp.MustCompile(`^dedup_test\.go:\d+: \[WARNING\] dedup 0\n\[WARNING\] last message repeated 3 times\n` +
+		`dedup_test\.go:\d+: \[ERROR\] dedup 4\n\[ERROR\] last message repeated 1 times\n` +
+		`dedup_test\.go:\d+: \[INFO\] dedup\n$`)
+	if result := output.String(); !exp.MatchString(result) {
+		t.Errorf("DedupReceiver messages didn't match\nEXP: %s^\nGOT: %s^", exp, result)
+	}
+}
+
+func TestDedupReceiverPrefix(t *testing.T) {
+	var output bytes.Buffer
+	dedup := NewDedupReceiver(NewCollector(&output, LDebug, "", 0), 0)
+	dedup.SetKeyFunc(IgnoreNumbers)
+	db, cache := New(LDebug, "db", 0), New(LDebug, "cache", 0)
+	db.AddReceiver(dedup)
+	cache.AddReceiver(dedup)
+
+	db.Warnf("timeout")
+	cache.Warnf("timeout")
+	for _, prefix := range []string{"db1", "db1", "db2"} {
+		dedup.LogEntry(&Entry{Severity: LWarn, Prefix: prefix, Message: "timeout"})
+	}
+	dedup.Flush()
+
+	exp := "[WARNING] db timeout\n[WARNING] cache timeout\n[WARNING] timeout\n[WARNING] last message repeated 1 times\n[WARNING] timeout\n"
+	if result := output.String(); result != exp {
+		t.Errorf("DedupReceiver messages didn't match\nEXP: %s^\nGOT: %s^", exp, result)
+	}
+}
+
+// fakeAfterFunc replaces d's timers, recording the delay and function of the last one scheduled.
+func fakeAfterFunc(d *DedupReceiver, delay *time.Duration, fire *func()) {
+	d.afterFunc = func(dt time.Duration, f func()) *time.Timer {
+		*delay, *fire = dt, f
+		return time.NewTimer(time.Hour)
+	}
+}
+
+func TestDedupReceiverWindow(t *testing.T) {
+	var output bytes.Buffer
+	now := time.Date(2009, 1, 23, 1, 23, 23, 0, time.UTC)
+	dedup := NewDedupReceiver(NewCollector(&output, LDebug, "", 0), 20*time.Millisecond)
+	dedup.now = func() time.Time { return now }
+	var delay time.Duration
+	var fire func()
+	fakeAfterFunc(dedup, &delay, &fire)
+	relay := New(LDebug, "", 0)
+	relay.AddReceiver(dedup)
+
+	relay.Warn("dedup")
+	relay.Warn("dedup")
+	relay.Warn("dedup")
+	if fire == nil || delay != 20*time.Millisecond {
+		t.Fatalf("DedupReceiver window timer didn't match\nEXP: %v^\nGOT: %v^", 20*time.Millisecond, delay)
+	}
+	now = now.Add(20 * time.Millisecond)
+	fire()
+	relay.Warn("dedup")
+
+	exp := "[WARNING] dedup\n[WARNING] last message repeated 2 times\n[WARNING] dedup\n"
+	if result := output.String(); result != exp {
+		t.Errorf("DedupReceiver messages didn't match\nEXP: %s^\nGOT: %s^", exp, result)
+	}
+}
+
+func TestDedupReceiverWindowBoundary(t *testing.T) {
+	var output bytes.Buffer
+	window := 200 * time.Millisecond
+	now := time.Date(2009, 1, 23, 1, 23, 23, 0, time.UTC)
+	dedup := NewDedupReceiver(NewCollector(&output, LDebug, "", 0), window)
+	dedup.now = func() time.Time { return now }
+	var delay time.Duration
+	var fire func()
+	fakeAfterFunc(dedup, &delay, &fire)
+	relay := New(LDebug, "", 0)
+	relay.AddReceiver(dedup)
+
+	relay.Warn("dedup")
+	now = now.Add(window * 3 / 5)
+	relay.Warn("dedup") // the window still runs from the first message
+	if delay != window*2/5 {
+		t.Errorf("DedupReceiver window timer didn't match\nEXP: %v^\nGOT: %v^", window*2/5, delay)
+	}
+	now = now.Add(window * 2 / 5)
+	fire()
+	exp := "[WARNING] dedup\n[WARNING] last message repeated 1 times\n"
+	if result := output.String(); result != exp {
+		t.Errorf("DedupReceiver messages at the end of the window didn't match\nEXP: %s^\nGOT: %s^", exp, result)
+	}
+
+	relay.Warn("dedup")
+	now = now.Add(window)
+	relay.Warn("dedup") // a window after the first, with no repeats, so passed on
+	exp += "[WARNING] dedup\n[WARNING] dedup\n"
+	if result := output.String(); result != exp {
+		t.Errorf("DedupReceiver messages after the window didn't match\nEXP: %s^\nGOT: %s^", exp, result)
+	}
+}
@@ -0,0 +1,72 @@
+package relog
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Field is a structured key/value pair attached to a log message.
+type Field struct {
+	Key   string
+	Value interface{}
+}
+
+// badKey is used as the key for values in a key/value list that lack a string key.
+const badKey = "!BADKEY"
+
+// makeFields converts a list of alternating keys and values into Fields.
+// Field values in the list are used as-is; a value without a string key is given the key !BADKEY.
+func makeFields(kv []interface{}) []Field {
+	if len(kv) == 0 {
+		return nil
+	}
+	fields := make([]Field, 0, (len(kv)+1)/2)
+	for i := 0; i < len(kv); i++ {
+		switch k := kv[i].(type) {
+		case Field:
+			fields = append(fields, k)
+		case string:
+			if i+1 < len(kv) {
+				fields = append(fields, Field{k, kv[i+1]})
+				i++
+			} else {
+				fields = append(fields, Field{badKey, k})
+			}
+		default:
+			fields = append(fields, Field{badKey, k})
+		}
+	}
+	return fields
+}
+
+// joinFields returns a new slice containing a followed by b, leaving both unmodified.
+func joinFields(a, b []Field) []Field {
+	if len(a) == 0 {
+		return b
+	}
+	if len(b) == 0 {
+		return a
+	}
+	fields := make([]Field, 0, len(a)+len(b))
+	return append(append(fields, a...), b...)
+}
+
+// formatFields renders fields in the form " key=value key=value", quoting values where needed.
+func formatFields(fields []Field) string {
+	if len(fields) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	for _, f := range fields {
+		b.WriteByte(' ')
+		b.WriteString(f.Key)
+		b.WriteByte('=')
+		s := fmt.Sprint(f.Value)
+		if s == "" || strings.ContainsAny(s, " \t\r\n\"=") {
+			s = strconv.Quote(s)
+		}
+		b.WriteString(s)
+	}
+	return b.String()
+}
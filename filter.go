@@ -0,0 +1,180 @@
+package relog
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// A Filter reports whether an entry should be logged. Filters are composed with And, Or and Not.
+type Filter func(e *Entry) bool
+
+// SeverityBand returns a Filter which passes entries with severities from min to max inclusive,
+// e.g. SeverityBand(LNotice, LInfo). As lower severities are more severe, min is the most severe severity passed.
+func SeverityBand(min, max int) Filter {
+	return func(e *Entry) bool { return e.Severity >= min && e.Severity <= max }
+}
+
+// SeverityIn returns a Filter which passes entries with any of the given severities.
+func SeverityIn(severities ...int) Filter {
+	set := make(map[int]bool, len(severities))
+	for _, severity := range severities {
+		set[severity] = true
+	}
+	return func(e *Entry) bool { return set[e.Severity] }
+}
+
+// HasPrefix returns a Filter which passes entries whose message begins with prefix, as a Relay's prefix does,
+// or whose Prefix begins with prefix.
+func HasPrefix(prefix string) Filter {
+	return func(e *Entry) bool {
+		return strings.HasPrefix(e.Message, prefix) || strings.HasPrefix(e.Prefix, prefix)
+	}
+}
+
+// MatchMessage returns a Filter which passes entries whose message, followed by its fields formatted as key=value pairs,
+// matches re.
+func MatchMessage(re *regexp.Regexp) Filter {
+	return func(e *Entry) bool { return re.MatchString(e.message() + formatFields(e.Fields)) }
+}
+
+// MatchFile returns a Filter which passes entries whose caller, as the full file path and line number
+// in the form "/a/b/file.go:23", matches re. The FilterReceiver must be set to resolve callers with SetCaller.
+func MatchFile(re *regexp.Regexp) Filter {
+	return func(e *Entry) bool { return re.MatchString(e.File + ":" + strconv.Itoa(e.Line)) }
+}
+
+// And returns a Filter which passes entries passed by all of filters.
+func And(filters ...Filter) Filter {
+	return func(e *Entry) bool {
+		for _, f := range filters {
+			if !f(e) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// Or returns a Filter which passes entries passed by any of filters.
+func Or(filters ...Filter) Filter {
+	return func(e *Entry) bool {
+		for _, f := range filters {
+			if f(e) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// Not returns a Filter which passes entries not passed by f.
+func Not(f Filter) Filter {
+	return func(e *Entry) bool { return !f(e) }
+}
+
+// FilterReceiver passes to its Receiver only the messages its Filter passes, so that e.g. one file can receive
+// Info and Notice messages while errors go elsewhere. The Filter is given an Entry with the message's
+// severity, message, fields and time, and with the full caller path if set by SetCaller;
+// its Prefix is empty, as the Receiver applies its own.
+// FilterReceiver implements the Receiver interface.
+type FilterReceiver struct {
+	rcvr   Receiver
+	filter atomic.Value // Filter
+	caller atomic.Bool
+}
+
+// NewFilterReceiver creates a new FilterReceiver which passes the messages filter passes to rcvr.
+func NewFilterReceiver(rcvr Receiver, filter Filter) *FilterReceiver {
+	f := &FilterReceiver{rcvr: rcvr}
+	f.filter.Store(filter)
+	return f
+}
+
+// SetFilter replaces the FilterReceiver's Filter.
+func (f *FilterReceiver) SetFilter(filter Filter) { f.filter.Store(filter) }
+
+// SetCaller sets whether the FilterReceiver resolves the caller of each message, giving the Filter
+// the Entry's File and Line, as MatchFile and predicates on the caller require. Resolving the caller is costly,
+// so is off by default; Entries passed to LogEntry keep whatever caller they were given.
+func (f *FilterReceiver) SetCaller(on bool) { f.caller.Store(on) }
+
+// allow reports whether the Filter passes a message logged from calldepth frames up.
+func (f *FilterReceiver) allow(severity int, calldepth int, msg string, fields []Field) bool {
+	e := Entry{Time: time.Now(), Severity: severity, Message: msg, Fields: fields}
+	if f.caller.Load() {
+		e.File, e.Line = caller(calldepth, Llongfile)
+	}
+	return f.filter.Load().(Filter)(&e)
+}
+
+// Flush flushes the Receiver if it is a Flusher.
+func (f *FilterReceiver) Flush() error { return flush(f.rcvr) }
+
+// Close closes the Receiver if it is a Closer, and otherwise flushes it.
+func (f *FilterReceiver) Close() error { return closeReceiver(f.rcvr) }
+
+// SetOutput calls SetOutput on the FilterReceiver's Receiver.
+func (f *FilterReceiver) SetOutput(w io.Writer) { f.rcvr.SetOutput(w) }
+
+// SetFlags calls SetFlags on the FilterReceiver's Receiver.
+func (f *FilterReceiver) SetFlags(flag int, maskOp int) { f.rcvr.SetFlags(flag, maskOp) }
+
+// SetPrefix calls SetPrefix on the FilterReceiver's Receiver.
+func (f *FilterReceiver) SetPrefix(prefix string) { f.rcvr.SetPrefix(prefix) }
+
+// SetVerbosity calls SetVerbosity on the FilterReceiver's Receiver.
+func (f *FilterReceiver) SetVerbosity(verbosity int) { f.rcvr.SetVerbosity(verbosity) }
+
+// Output passes s to the Receiver's Output if the Filter passes it at severity Notice.
+func (f *FilterReceiver) Output(calldepth int, s string) error {
+	if !f.allow(LNotice, calldepth+1, s, nil) {
+		return nil
+	}
+	return f.rcvr.Output(calldepth+1, s)
+}
+
+// Log passes the message to the Receiver if the Filter passes it.
+func (f *FilterReceiver) Log(severity int, calldepth int, v ...interface{}) {
+	if f.allow(severity, calldepth+1, fmt.Sprint(v...), nil) {
+		f.rcvr.Log(severity, calldepth+1, v...)
+	}
+}
+
+// Logf passes the message to the Receiver if the Filter passes it.
+func (f *FilterReceiver) Logf(severity int, calldepth int, format string, v ...interface{}) {
+	if f.allow(severity, calldepth+1, fmt.Sprintf(format, v...), nil) {
+		f.rcvr.Logf(severity, calldepth+1, format, v...)
+	}
+}
+
+// Logln passes the message to the Receiver if the Filter passes it.
+func (f *FilterReceiver) Logln(severity int, calldepth int, v ...interface{}) {
+	if f.allow(severity, calldepth+1, fmt.Sprintln(v...), nil) {
+		f.rcvr.Logln(severity, calldepth+1, v...)
+	}
+}
+
+// Logw passes msg and fields to the Receiver if the Filter passes them.
+func (f *FilterReceiver) Logw(severity int, calldepth int, msg string, fields []Field) {
+	if !f.allow(severity, calldepth+1, msg, fields) {
+		return
+	}
+	if fr, ok := f.rcvr.(FieldReceiver); ok {
+		fr.Logw(severity, calldepth+1, msg, fields)
+	} else {
+		f.rcvr.Log(severity, calldepth+1, msg+formatFields(fields))
+	}
+}
+
+// LogEntry passes e to the Receiver if the Filter passes it.
+func (f *FilterReceiver) LogEntry(e *Entry) error {
+	if !f.filter.Load().(Filter)(e) {
+		return nil
+	}
+	return logEntry(f.rcvr, e)
+}
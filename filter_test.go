@@ -0,0 +1,46 @@
+package relog
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+var FilterTests = []struct {
+	name   string
+	filter Filter
+	caller bool
+	exp    string
+}{
+	{"band", SeverityBand(LNotice, LInfo), false, "[NOTICE] db notice\n[INFO] http info n=1\n"},
+	{"set", SeverityIn(LError, LDebug), false, "[ERROR] db error\n[DEBUG] http debug\n"},
+	{"prefix", HasPrefix("db"), false, "[ERROR] db error\n[NOTICE] db notice\n"},
+	{"regex", MatchMessage(regexp.MustCompile(`n=1$`)), false, "[INFO] http info n=1\n"},
+	{"predicate", func(e *Entry) bool { return strings.HasSuffix(e.File, "filter_test.go") && e.Severity == LNotice }, true, "[NOTICE] db notice\n"},
+	{"file", And(SeverityIn(LError), MatchFile(regexp.MustCompile(`/filter_test\.go:\d+$`))), true, "[ERROR] db error\n"},
+	{"no caller", func(e *Entry) bool { return e.File == "" && e.Severity == LDebug }, false, "[DEBUG] http debug\n"},
+	{"and", And(HasPrefix("http"), SeverityBand(LEmerg, LInfo)), false, "[INFO] http info n=1\n"},
+	{"or", Or(SeverityIn(LError), HasPrefix("http")), false, "[ERROR] db error\n[INFO] http info n=1\n[DEBUG] http debug\n"},
+	{"not", Not(Or(HasPrefix("http"), SeverityIn(LError))), false, "[NOTICE] db notice\n"},
+}
+
+func TestFilterReceiver(t *testing.T) {
+	for _, test := range FilterTests {
+		var output bytes.Buffer
+		relay := New(LDebug, "", 0)
+		filter := NewFilterReceiver(NewCollector(&output, LDebug, "", Llongfile), test.filter)
+		filter.SetCaller(test.caller)
+		relay.AddReceiver(filter)
+		relay.SetFlags(0, NONE)
+
+		relay.Error("db error")
+		relay.Noticef("db %s", "notice")
+		relay.Infow("http info", "n", 1)
+		relay.Debugln("http debug")
+
+		if result := output.String(); result != test.exp {
+			t.Errorf("%s filter output didn't match\nEXP: %s^\nGOT: %s^", test.name, test.exp, result)
+		}
+	}
+}
@@ -0,0 +1,168 @@
+package relog
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Entry is a single log message, as passed to a Formatter.
+type Entry struct {
+	Time     time.Time
+	Severity int
+	Prefix   string
+	Message  string
+	Fields   []Field
+	File     string // set only if Flag includes Lshortfile or Llongfile
+	Line     int
+	Flag     int    // the output flags of the Collector writing the Entry
+	Stack    string // the logging goroutine's stack, set only if captured for the Entry's severity
+
+	output bool   // written via Output, so laid out without a severity label where the format allows
+	format string // the format string Message was formatted from, if logged via Logf
+}
+
+// A Formatter writes the representation of an Entry to w. Formatters should honour the Entry's Flag
+// where applicable, and should terminate the entry with a newline.
+type Formatter interface {
+	Format(w io.Writer, e *Entry) error
+}
+
+// caller returns the file and line number of the frame calldepth frames above its caller,
+// with the file shortened to its final element if flag includes Lshortfile.
+func caller(calldepth int, flag int) (string, int) {
+	_, file, line, ok := runtime.Caller(calldepth + 1)
+	if !ok {
+		return "???", 0
+	}
+	if flag&Lshortfile != 0 {
+		file = filepath.Base(file)
+	}
+	return file, line
+}
+
+// setFlag sets the Entry's Flag, shortening or removing its resolved caller to match.
+func (e *Entry) setFlag(flag int) {
+	e.Flag = flag
+	switch {
+	case e.File == "":
+	case flag&Lshortfile != 0:
+		e.File = filepath.Base(e.File)
+	case flag&Llongfile == 0:
+		e.File, e.Line = "", 0
+	}
+}
+
+// message returns the Entry's message without its trailing newline, if any.
+func (e *Entry) message() string {
+	return strings.TrimSuffix(e.Message, "\n")
+}
+
+// time returns the Entry's time, in UTC if its Flag includes LUTC.
+func (e *Entry) time() time.Time {
+	if e.Flag&LUTC != 0 {
+		return e.Time.UTC()
+	}
+	return e.Time
+}
+
+// TextFormatter lays out entries as package log does, followed by the severity label, message and fields,
+// and by the stack, if captured, on the following lines:
+//
+//	prefix 2009/01/23 01:23:23 d.go:23: [ERROR] message key=value
+//
+// Entries written via Output have no severity label, so that their text matches log.Logger's Output.
+type TextFormatter struct{}
+
+// Format writes the Entry in text form.
+func (TextFormatter) Format(w io.Writer, e *Entry) error {
+	var b bytes.Buffer
+	b.WriteString(e.Prefix)
+	if e.Flag&(Ldate|Ltime|Lmicroseconds) != 0 {
+		t := e.time()
+		if e.Flag&Ldate != 0 {
+			b.WriteString(t.Format("2006/01/02 "))
+		}
+		if e.Flag&(Ltime|Lmicroseconds) != 0 {
+			if e.Flag&Lmicroseconds != 0 {
+				b.WriteString(t.Format("15:04:05.000000 "))
+			} else {
+				b.WriteString(t.Format("15:04:05 "))
+			}
+		}
+	}
+	if e.File != "" {
+		b.WriteString(e.File + ":" + strconv.Itoa(e.Line) + ": ")
+	}
+	if !e.output {
+		b.WriteString("[" + severityLabel(e.Severity) + "] ")
+	}
+	if len(e.Fields) > 0 {
+		b.WriteString(e.message() + formatFields(e.Fields))
+	} else {
+		b.WriteString(e.Message)
+	}
+	if b.Len() == 0 || b.Bytes()[b.Len()-1] != '\n' {
+		b.WriteByte('\n')
+	}
+	if e.Stack != "" {
+		b.WriteString(e.Stack + "\n")
+	}
+	_, err := w.Write(b.Bytes())
+	return err
+}
+
+// LogfmtFormatter lays out entries as logfmt key=value pairs, with the stack, if captured, as a final stack pair:
+//
+//	time=2009-01-23T01:23:23.123123Z severity=ERROR prefix=pre caller=d.go:23 msg="message text" key=value
+type LogfmtFormatter struct{}
+
+// Format writes the Entry in logfmt form.
+func (LogfmtFormatter) Format(w io.Writer, e *Entry) error {
+	fields := make([]Field, 0, 5+len(e.Fields))
+	fields = append(fields, Field{"time", e.time().Format(jsonTimeFormat)}, Field{"severity", severityLabel(e.Severity)})
+	if e.Prefix != "" {
+		fields = append(fields, Field{"prefix", e.Prefix})
+	}
+	if e.File != "" {
+		fields = append(fields, Field{"caller", e.File + ":" + strconv.Itoa(e.Line)})
+	}
+	fields = append(fields, Field{"msg", e.message()})
+	fields = append(fields, e.Fields...)
+	fields = stackField(fields, e.Stack)
+	_, err := io.WriteString(w, formatFields(fields)[1:]+"\n")
+	return err
+}
+
+// GlogFormatter lays out entries with the header used by github.com/golang/glog:
+//
+//	Lmmdd hh:mm:ss.uuuuuu threadid file:line] prefix message key=value
+//
+// where L is I for Debug, Info and Notice, W for Warn, E for Error and F for more severe entries.
+// The thread id is the process id. The stack, if captured, follows on the next lines.
+type GlogFormatter struct{}
+
+// glogSeverities holds the glog severity character for each severity.
+var glogSeverities = []byte{'F', 'F', 'F', 'E', 'W', 'I', 'I', 'I'}
+
+// Format writes the Entry with a glog header.
+func (GlogFormatter) Format(w io.Writer, e *Entry) error {
+	var b bytes.Buffer
+	b.WriteByte(glogSeverities[syslogSeverity(e.Severity)])
+	b.WriteString(e.time().Format("0102 15:04:05.000000 "))
+	b.WriteString(strconv.Itoa(os.Getpid()) + " ")
+	if e.File != "" {
+		b.WriteString(e.File + ":" + strconv.Itoa(e.Line))
+	}
+	b.WriteString("] " + e.Prefix + e.message() + formatFields(e.Fields) + "\n")
+	if e.Stack != "" {
+		b.WriteString(e.Stack + "\n")
+	}
+	_, err := w.Write(b.Bytes())
+	return err
+}
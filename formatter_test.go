@@ -0,0 +1,42 @@
+package relog
+
+import (
+	"bytes"
+	"regexp"
+	"testing"
+	"time"
+)
+
+var FormatterTests = []struct {
+	formatter Formatter
+	match     string
+}{
+	{TextFormatter{}, `^pre2009/01/23 01:23:23\.123123 d\.go:23: \[ERROR\] two words key="a b"\n$`},
+	{LogfmtFormatter{}, `^time=2009-01-23T01:23:23\.123123Z severity=ERROR prefix=pre caller=d\.go:23 msg="two words" key="a b"\n$`},
+	{GlogFormatter{}, `^E0123 01:23:23\.123123 \d+ d\.go:23\] pretwo words key="a b"\n$`},
+	{ConsoleFormatter{}, `^2009/01/23 01:23:23\.123123 \[ERROR\]     d\.go:23              pretwo words {29}key="a b"\n$`},
+	{ConsoleFormatter{Color: true}, `^2009/01/23 01:23:23\.123123 \x1b\[91m\[ERROR\]\x1b\[0m     \x1b\[2md\.go:23\x1b\[0m              pretwo words {29}\x1b\[36mkey\x1b\[0m="a b"\n$`},
+	{JSONFormatter{}, `^{"time":"2009-01-23T01:23:23\.123123Z","severity":"ERROR","severity_num":3,"prefix":"pre","file":"d\.go","line":23,"msg":"two words","key":"a b"}\n$`},
+}
+
+func TestFormatters(t *testing.T) {
+	e := Entry{
+		Time:     time.Date(2009, 1, 23, 1, 23, 23, 123123000, time.UTC),
+		Severity: LError,
+		Prefix:   "pre",
+		Message:  "two words\n",
+		Fields:   []Field{{"key", "a b"}},
+		File:     "d.go",
+		Line:     23,
+		Flag:     Ldate | Lmicroseconds | Lshortfile | LUTC,
+	}
+	var output bytes.Buffer
+	for _, test := range FormatterTests {
+		output.Reset()
+		if err := test.formatter.Format(&output, &e); err != nil {
+			t.Errorf("%T returned error: %s", test.formatter, err)
+		} else if result := output.String(); !regexp.MustCompile(test.match).MatchString(result) {
+			t.Errorf("%T output didn't match\nEXP: %s^\nGOT: %s^", test.formatter, test.match, result)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package relog
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// jsonTimeFormat is the layout of timestamps written by the JSON and logfmt formatters.
+const jsonTimeFormat = "2006-01-02T15:04:05.000000Z07:00"
+
+// jsonReservedKeys are the members written for every JSON entry; fields using these keys are written as "fields.<key>".
+var jsonReservedKeys = map[string]bool{
+	"time": true, "severity": true, "severity_num": true, "prefix": true, "file": true, "line": true, "msg": true,
+	"stack": true,
+}
+
+// NewJSONCollector creates a new Collector which writes each message to w as a single line JSON object
+// using JSONFormatter.
+func NewJSONCollector(w io.Writer, verbosity int, prefix string, flag int) *Collector {
+	return NewCollector(w, verbosity, prefix, flag, JSONFormatter{})
+}
+
+// JSONFormatter lays out each entry as a single line JSON object, with members time, severity, severity_num,
+// prefix, file and line (when Lshortfile or Llongfile is set), msg, stack (when captured), followed by any fields.
+// The time is always written, in UTC if LUTC is set; other time flags are ignored.
+// Invalid UTF-8 is replaced with U+FFFD.
+type JSONFormatter struct{}
+
+// Format writes the Entry as a JSON object.
+func (JSONFormatter) Format(w io.Writer, e *Entry) error {
+	var b bytes.Buffer
+	b.WriteString(`{"time":`)
+	appendJSON(&b, e.time().Format(jsonTimeFormat))
+	b.WriteString(`,"severity":`)
+	appendJSON(&b, severityLabel(e.Severity))
+	b.WriteString(`,"severity_num":` + strconv.Itoa(e.Severity))
+	if e.Prefix != "" {
+		b.WriteString(`,"prefix":`)
+		appendJSON(&b, e.Prefix)
+	}
+	if e.File != "" {
+		b.WriteString(`,"file":`)
+		appendJSON(&b, e.File)
+		b.WriteString(`,"line":` + strconv.Itoa(e.Line))
+	}
+	b.WriteString(`,"msg":`)
+	appendJSON(&b, e.message())
+	if e.Stack != "" {
+		b.WriteString(`,"stack":`)
+		appendJSON(&b, e.Stack)
+	}
+	for _, f := range e.Fields {
+		key := f.Key
+		if jsonReservedKeys[key] {
+			key = "fields." + key
+		}
+		b.WriteByte(',')
+		appendJSON(&b, key)
+		b.WriteByte(':')
+		appendJSON(&b, f.Value)
+	}
+	b.WriteString("}\n")
+	_, err := w.Write(b.Bytes())
+	return err
+}
+
+// appendJSON writes the JSON encoding of v to b, without escaping HTML characters.
+// Errors are encoded as their message, and values that can't be encoded as JSON are encoded as their fmt.Sprint string.
+func appendJSON(b *bytes.Buffer, v interface{}) {
+	if err, ok := v.(error); ok {
+		if _, ok := v.(json.Marshaler); !ok {
+			v = err.Error()
+		}
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		buf.Reset()
+		enc.Encode(fmt.Sprint(v))
+	}
+	b.Write(bytes.TrimSuffix(buf.Bytes(), []byte("\n")))
+}
@@ -0,0 +1,47 @@
+package relog
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestJSONCollector(t *testing.T) {
+	var output bytes.Buffer
+	collector := NewJSONCollector(&output, LInfo, "pre", Lshortfile|LUTC)
+
+	collector.Log(LDebug, 1, "dropped")
+	if output.Len() != 0 {
+		t.Errorf("JSON Collector logged above its verbosity: %s", output.String())
+	}
+
+	collector.Logw(LError, 1, "line one\n\"quoted\"\xff", []Field{{"id", 7}, {"msg", "field"}, {"err", errors.New("failed")}})
+	result := output.String()
+	if strings.Count(result, "\n") != 1 || !strings.HasSuffix(result, "\n") {
+		t.Fatalf("JSON Collector didn't write a single line: %q", result)
+	}
+	var entry map[string]interface{}
+	if err := json.Unmarshal([]byte(result), &entry); err != nil {
+		t.Fatalf("JSON Collector wrote invalid JSON: %s\n%s", err, result)
+	}
+	exp := map[string]interface{}{
+		"severity":     "ERROR",
+		"severity_num": 3.0,
+		"prefix":       "pre",
+		"file":         "json_test.go",
+		"msg":          "line one\n\"quoted\"�",
+		"id":           7.0,
+		"fields.msg":   "field",
+		"err":          "failed",
+	}
+	for k, v := range exp {
+		if entry[k] != v {
+			t.Errorf("JSON Collector member %s didn't match\nEXP: %v\nGOT: %v", k, v, entry[k])
+		}
+	}
+	if s, _ := entry["time"].(string); !strings.HasSuffix(s, "Z") {
+		t.Errorf("JSON Collector time not in UTC: %v", entry["time"])
+	}
+}
@@ -0,0 +1,154 @@
+package relog
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// customSeverity describes a severity defined with DefineSeverity.
+type customSeverity struct {
+	label  string
+	syslog int // the built-in severity, LEmerg to LDebug, the severity is sent to syslog as
+}
+
+// severityRegistry holds the custom severities, by rank and by lower case name.
+type severityRegistry struct {
+	byRank map[int]customSeverity
+	byName map[string]Severity
+}
+
+var (
+	customMu         sync.Mutex   // serializes definitions
+	customSeverities atomic.Value // *severityRegistry, replaced rather than modified when a severity is defined
+)
+
+// DefineSeverity defines a custom severity with the given name and rank, which is sent to syslog, and mapped onto
+// glog, slog and console output, as the built-in severity syslogSeverity. The rank is compared against verbosities as
+// the built-in severities are, so a rank above LDebug, e.g. LDebug+1 for TRACE, is only logged at verbosities of at
+// least that rank, and a negative rank, e.g. -1 for AUDIT, is logged at every verbosity from LEmerg on.
+// The name's upper case form is used as the severity's label, and ParseSeverity accepts the name in any case.
+func DefineSeverity(name string, rank int, syslogSeverity int) (Severity, error) {
+	key := strings.ToLower(strings.TrimSpace(name))
+	if key == "" || strings.ContainsAny(key, " \t\n=,") {
+		return 0, fmt.Errorf("relog: invalid severity name %q", name)
+	}
+	if syslogSeverity < LEmerg || syslogSeverity > LDebug {
+		return 0, fmt.Errorf("relog: invalid syslog severity %d for severity %q", syslogSeverity, name)
+	}
+	customMu.Lock()
+	defer customMu.Unlock()
+	reg := severityRegistryLoad()
+	if _, ok := severityNames[key]; ok {
+		return 0, fmt.Errorf("relog: severity %q is already defined", name)
+	}
+	if _, ok := reg.byName[key]; ok {
+		return 0, fmt.Errorf("relog: severity %q is already defined", name)
+	}
+	if _, ok := reg.byRank[rank]; ok || (rank >= LEmerg && rank < len(severities)) {
+		return 0, fmt.Errorf("relog: severity %d is already defined", rank)
+	}
+	next := &severityRegistry{
+		byRank: make(map[int]customSeverity, len(reg.byRank)+1),
+		byName: make(map[string]Severity, len(reg.byName)+1),
+	}
+	for k, v := range reg.byRank {
+		next.byRank[k] = v
+	}
+	for k, v := range reg.byName {
+		next.byName[k] = v
+	}
+	next.byRank[rank] = customSeverity{label: strings.ToUpper(key), syslog: syslogSeverity}
+	next.byName[key] = Severity(rank)
+	customSeverities.Store(next)
+	return Severity(rank), nil
+}
+
+// MustDefineSeverity is like DefineSeverity but panics if the severity cannot be defined.
+// It simplifies the initialization of package level severities:
+//
+//	var Trace = relog.MustDefineSeverity("trace", relog.LDebug+1, relog.LDebug)
+func MustDefineSeverity(name string, rank int, syslogSeverity int) Severity {
+	severity, err := DefineSeverity(name, rank, syslogSeverity)
+	if err != nil {
+		panic(err)
+	}
+	return severity
+}
+
+// severityRegistryLoad returns the current custom severities.
+func severityRegistryLoad() *severityRegistry {
+	if reg, ok := customSeverities.Load().(*severityRegistry); ok {
+		return reg
+	}
+	return &severityRegistry{}
+}
+
+// definedSeverity reports whether severity is a built-in or custom severity.
+func definedSeverity(severity int) bool {
+	if severity >= LEmerg && severity < len(severities) {
+		return true
+	}
+	_, ok := severityRegistryLoad().byRank[severity]
+	return ok
+}
+
+// severityLabel returns the label of a built-in or custom severity, or its number if it has none.
+func severityLabel(severity int) string {
+	return Severity(severity).String()
+}
+
+// syslogSeverity maps a severity onto the built-in severities: custom severities to the syslog severity they were
+// defined with, and undefined severities to LEmerg or LDebug, whichever is nearer.
+func syslogSeverity(severity int) int {
+	if severity >= LEmerg && severity <= LDebug {
+		return severity
+	}
+	if c, ok := severityRegistryLoad().byRank[severity]; ok {
+		return c.syslog
+	}
+	if severity < LEmerg {
+		return LEmerg
+	}
+	return LDebug
+}
+
+// Level logs messages at a single severity through a Relay. It provides the convenience methods
+// for custom severities that the Relay has for the built-in ones:
+//
+//	var Trace = relog.MustDefineSeverity("trace", relog.LDebug+1, relog.LDebug)
+//
+//	relog.At(Trace).Printf("state %v", state)
+type Level struct {
+	r        *Relay
+	severity int
+}
+
+// At returns a Level which logs at severity through the Relay.
+func At(severity Severity) Level { return std.At(severity) }
+func (r *Relay) At(severity Severity) Level {
+	return Level{r: r, severity: int(severity)}
+}
+
+// levelCalldepth is the calldepth of a Level's caller, which calls the Level's method directly.
+const levelCalldepth = 2
+
+// Print calls Log with the Level's severity.
+func (l Level) Print(v ...interface{}) { l.r.Log(l.severity, levelCalldepth, v...) }
+
+// Printf calls Logf with the Level's severity.
+func (l Level) Printf(format string, v ...interface{}) {
+	l.r.Logf(l.severity, levelCalldepth, format, v...)
+}
+
+// Println calls Logln with the Level's severity.
+func (l Level) Println(v ...interface{}) { l.r.Logln(l.severity, levelCalldepth, v...) }
+
+// Printw calls Logw with the Level's severity and the given alternating keys and values as fields.
+func (l Level) Printw(msg string, kv ...interface{}) {
+	l.r.Logw(l.severity, levelCalldepth, msg, makeFields(kv))
+}
+
+// Severity returns the Level's severity.
+func (l Level) Severity() Severity { return Severity(l.severity) }
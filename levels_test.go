@@ -0,0 +1,117 @@
+package relog
+
+import (
+	"bytes"
+	"regexp"
+	"sync"
+	"testing"
+)
+
+// The ranks of the custom severities defined for tests, far from those other tests use or expect to be undefined.
+const (
+	testTrace = LDebug + 100
+	testAudit = -100
+)
+
+var defineOnce sync.Once
+
+// defineTestSeverities defines the custom severities used by tests, once per test binary.
+func defineTestSeverities(t *testing.T) {
+	defineOnce.Do(func() {
+		for _, s := range []struct {
+			name         string
+			rank, syslog int
+		}{{"trace", testTrace, LDebug}, {"Audit", testAudit, LNotice}} {
+			if _, err := DefineSeverity(s.name, s.rank, s.syslog); err != nil {
+				t.Fatal(err)
+			}
+		}
+	})
+}
+
+var DefineSeverityTests = []struct {
+	name   string
+	rank   int
+	syslog int
+}{
+	{"trace", 20, LDebug},
+	{"WARN", 21, LWarn},
+	{"verbose", LInfo, LInfo},
+	{"verbose", testTrace, LDebug},
+	{"verbose", 22, 8},
+	{"", 23, LDebug},
+	{"too verbose", 24, LDebug},
+}
+
+func TestDefineSeverity(t *testing.T) {
+	defineTestSeverities(t)
+	for _, test := range DefineSeverityTests {
+		if _, err := DefineSeverity(test.name, test.rank, test.syslog); err == nil {
+			t.Errorf("DefineSeverity(%q, %d, %d) didn't fail", test.name, test.rank, test.syslog)
+		}
+	}
+	for in, exp := range map[string]Severity{"TRACE": testTrace, " audit": testAudit, "-100": testAudit} {
+		if severity, err := ParseSeverity(in); err != nil || severity != exp {
+			t.Errorf("ParseSeverity(%q) didn't match\nEXP: %v^\nGOT: %v, %v^", in, exp, severity, err)
+		}
+	}
+	if s := Severity(testAudit).String(); s != "AUDIT" {
+		t.Errorf("String didn't match\nEXP: %s^\nGOT: %s^", "AUDIT", s)
+	}
+	if s := syslogSeverity(testAudit); s != LNotice {
+		t.Errorf("syslogSeverity didn't match\nEXP: %d^\nGOT: %d^", LNotice, s)
+	}
+	if s := syslogSeverity(-5); s != LEmerg {
+		t.Errorf("syslogSeverity of an undefined severity didn't match\nEXP: %d^\nGOT: %d^", LEmerg, s)
+	}
+}
+
+var CustomFormatTests = []struct {
+	formatter Formatter
+	severity  int
+	exp       string
+}{
+	{TextFormatter{}, testTrace, `^\[TRACE\] message\n$`},
+	{TextFormatter{}, 30, `^\[30\] message\n$`},
+	{TextFormatter{}, -7, `^\[-7\] message\n$`},
+	{JSONFormatter{}, testAudit, `^{"time":"[^"]+","severity":"AUDIT","severity_num":-100,"msg":"message"}\n$`},
+	{LogfmtFormatter{}, testTrace, `severity=TRACE msg=message\n$`},
+	{GlogFormatter{}, testTrace, `^I`},
+	{GlogFormatter{}, -7, `^F`},
+	{ConsoleFormatter{Color: true}, testAudit, `^\x1b\[36m\[AUDIT\]\x1b\[0m +message\n$`},
+	{ConsoleFormatter{}, 30, `^\[30\] +message\n$`},
+}
+
+func TestCustomSeverityFormat(t *testing.T) {
+	defineTestSeverities(t)
+	for _, test := range CustomFormatTests {
+		var b bytes.Buffer
+		if err := test.formatter.Format(&b, &Entry{Severity: test.severity, Message: "message"}); err != nil {
+			t.Fatal(err)
+		}
+		if !regexp.MustCompile(test.exp).Match(b.Bytes()) {
+			t.Errorf("%T output didn't match\nEXP: %s^\nGOT: %s^", test.formatter, test.exp, b.String())
+		}
+	}
+}
+
+func TestLevel(t *testing.T) {
+	defineTestSeverities(t)
+	var b bytes.Buffer
+	r := New(LDebug, "", 0)
+	r.AddReceiver(NewCollector(&b, testTrace, "", Lshortfile))
+	r.At(testTrace).Printf("hidden %d", 1)
+	r.At(testAudit).Printw("always", "user", "ann")
+	exp := `^levels_test.go:\d+: \[AUDIT\] always user=ann\n$`
+	if !regexp.MustCompile(exp).MatchString(b.String()) {
+		t.Errorf("Level output didn't match\nEXP: %s^\nGOT: %s^", exp, b.String())
+	}
+
+	b.Reset()
+	r.SetVerbosity(testTrace)
+	r.At(testTrace).Println("shown")
+	exp = `^levels_test.go:\d+: \[TRACE\] shown\n$`
+	if !regexp.MustCompile(exp).MatchString(b.String()) {
+		t.Errorf("Level output didn't match\nEXP: %s^\nGOT: %s^", exp, b.String())
+	}
+}
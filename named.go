@@ -0,0 +1,68 @@
+package relog
+
+import "strings"
+
+// loggerKey is the key of the field carrying a named Relay's name.
+const loggerKey = "logger"
+
+// Named returns the child of the Relay with the given name, creating it if necessary. The child's name is
+// the Relay's name and the given name joined by a dot, e.g. "db.pool", and is bound to its messages
+// as the field "logger". The child forwards messages to its parent's receivers, followed by any added to it,
+// and takes its verbosity, prefix, flags and vmodule rules from its parent until they are set on it.
+// The children of the standard Relay, and their children in turn, are registered under their names, so can be found
+// with Get and adjusted via AdminHandler; a name that is already registered is left to its receiver.
+// The named children of other Relays, including those derived by With, are not registered.
+func Named(name string) *Relay { return std.Named(name) }
+func (r *Relay) Named(name string) *Relay {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if c, ok := r.children[name]; ok {
+		return c
+	}
+	full := name
+	if r.name != "" {
+		full = r.name + "." + name
+	}
+	c := newRelay(LDebug, "", 0, 2, nil)
+	c.name, c.parent = full, r
+	fields := make([]Field, 0, len(r.fields)+1)
+	for _, f := range r.fields {
+		if f.Key != loggerKey {
+			fields = append(fields, f)
+		}
+	}
+	c.fields = append(fields, Field{loggerKey, full})
+	if r.children == nil {
+		r.children = make(map[string]*Relay)
+	}
+	r.children[name] = c
+	if r.registered() {
+		registerNew(full, c)
+	}
+	return c
+}
+
+// Child returns the descendant of the Relay at the given dotted path, creating it and its ancestors if necessary,
+// so that r.Child("db.pool") is r.Named("db").Named("pool").
+func Child(path string) *Relay { return std.Child(path) }
+func (r *Relay) Child(path string) *Relay {
+	for _, name := range strings.Split(path, ".") {
+		r = r.Named(name)
+	}
+	return r
+}
+
+// registered reports whether the Relay is the standard Relay or a named Relay registered under its name,
+// so that its named children are registered too.
+func (r *Relay) registered() bool {
+	return r == std || (r.name != "" && Lookup(r.name) == Receiver(r))
+}
+
+// Name returns the Relay's dotted name, or "" if it was not created by Named or Child.
+func (r *Relay) Name() string { return r.name }
+
+// Get returns the named Relay registered under name, such as one created by Named or Child, or nil if there is none.
+func Get(name string) *Relay {
+	r, _ := Lookup(name).(*Relay)
+	return r
+}
@@ -0,0 +1,73 @@
+package relog
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestNamedRelay(t *testing.T) {
+	var output, poolOutput bytes.Buffer
+	relay := New(LInfo, "app", 0)
+	relay.AddWriter(&output, LDebug, "", 0)
+
+	db := relay.Named("named_test")
+	pool := relay.Child("named_test.pool")
+	if db.Child("pool") != pool || pool.Name() != "named_test.pool" {
+		t.Fatal("Child didn't return the existing named relay")
+	}
+	pool.AddWriter(&poolOutput, LDebug, "", 0)
+
+	pool.Infof("inherited")
+	pool.Debugf("hidden")
+	relay.SetVerbosity(LDebug)
+	relay.SetPrefix("svc")
+	pool.Debugf("follows parent")
+	db.SetVerbosity(LWarn)
+	db.SetPrefix("db")
+	pool.Infof("hidden")
+	pool.Warnf("follows db")
+	pool.SetVerbosity(LDebug)
+	pool.Debugf("own setting")
+	relay.With("k", 1).Named("named_test").Warnf("derived")
+	derived := db.With("k", 2).Named("pool")
+	derived.Infof("hidden")
+	derived.Warnf("derived child")
+
+	exp := "[INFO] app inherited logger=named_test.pool\n" +
+		"[DEBUG] svc follows parent logger=named_test.pool\n" +
+		"[WARNING] db follows db logger=named_test.pool\n" +
+		"[DEBUG] db own setting logger=named_test.pool\n" +
+		"[WARNING] svc derived k=1 logger=named_test\n" +
+		"[WARNING] db derived child k=2 logger=named_test.pool\n"
+	if result := output.String(); result != exp {
+		t.Errorf("Named relay output didn't match\nEXP: %s^\nGOT: %s^", exp, result)
+	}
+	exp = exp[:strings.Index(exp, "[WARNING] svc derived")]
+	if result := poolOutput.String(); result != exp {
+		t.Errorf("Child receiver output didn't match\nEXP: %s^\nGOT: %s^", exp, result)
+	}
+}
+
+func TestNamedRegistry(t *testing.T) {
+	db := Named("named_registry")
+	pool := Child("named_registry.pool")
+	t.Cleanup(func() {
+		Unregister("named_registry")
+		Unregister("named_registry.pool")
+		std.mu.Lock()
+		delete(std.children, "named_registry")
+		std.mu.Unlock()
+	})
+	if Get("named_registry") != db || Get("named_registry.pool") != pool {
+		t.Fatal("Get didn't return the standard Relay's named children")
+	}
+	New(LInfo, "", 0).Named("named_registry")
+	db.With("k", 1).Named("pool")
+	if Get("named_registry") != db || Get("named_registry.pool") != pool {
+		t.Error("Named children of other relays replaced the registered relays")
+	}
+	if r := New(LInfo, "", 0).Child("named_other.pool"); Get("named_other") != nil || Get(r.Name()) != nil {
+		t.Error("Named children of another root relay were registered")
+	}
+}
@@ -4,9 +4,9 @@
 	It defines a Receiver interface which can handle the Print/Panic/Fatal calls from
 	standard package log, as well as prioritized messages e.g. Alert, Debug.
 	Receiver is implemented by 1) the Relay type that routes log messages to its registered
-	Receivers based on the priority of the log message, and 2) the Collector type which logs
-	the messages it receives via its embedded log.Logger.
-	Any io.Writer can be registered as a Collector's Logger.
+	Receivers based on the priority of the log message, and 2) the Collector type which writes
+	the messages it receives to an io.Writer, laid out by its Formatter.
+	Any io.Writer can be registered as a Collector's output, and text, logfmt, JSON and glog-style Formatters are provided.
 
 	The log levels, and much of the terminology, stem largely from https://tools.ietf.org/html/rfc3164.
 
@@ -60,3 +60,69 @@ type Receiver interface {
 	SetPrefix(prefix string)                                           // Set the value to prepend to Receiver's log statements
 	SetVerbosity(verbosity int)                                        // Set the level at or above which receiver will generate a log statement
 }
+
+// A FieldReceiver is a Receiver that accepts structured fields alongside a message.
+// Relays pass fields to receivers that implement FieldReceiver, and render them into the message for those that don't.
+type FieldReceiver interface {
+	Receiver
+	Logw(severity int, calldepth int, msg string, fields []Field) // Logw msg and fields at given severity level
+}
+
+// formatReceiver is a FieldReceiver that also takes the format string msg was formatted from, if any,
+// so that a Relay which formats messages before forwarding them still passes on their format.
+type formatReceiver interface {
+	FieldReceiver
+	logwf(severity int, calldepth int, format string, msg string, fields []Field)
+}
+
+// An EntryReceiver is a Receiver that can log a complete Entry whose caller has already been resolved,
+// as is necessary when an entry is logged from a different goroutine than the one that created it.
+// The Entry's File holds the full path of the caller, if known; its Prefix and Flag are ignored.
+type EntryReceiver interface {
+	Receiver
+	LogEntry(e *Entry) error // Log e, subject to the receiver's verbosity
+}
+
+// A Flusher is a Receiver that buffers messages, and can write out those it holds on demand.
+// Relays flush the receivers that implement Flusher when flushed, and before exiting from Fatal.
+type Flusher interface {
+	Receiver
+	Flush() error // Write out any buffered messages, waiting until they are written
+}
+
+// A Closer is a Receiver that holds resources, such as files, connections or goroutines, to be released when logging ends.
+// Relays close the receivers that implement Closer when closed, and flush those that don't.
+type Closer interface {
+	Receiver
+	Close() error // Flush any buffered messages and release the receiver's resources
+}
+
+// flush flushes rcvr if it is a Flusher.
+func flush(rcvr Receiver) error {
+	if f, ok := rcvr.(Flusher); ok {
+		return f.Flush()
+	}
+	return nil
+}
+
+// closeReceiver closes rcvr if it is a Closer, and otherwise flushes it.
+func closeReceiver(rcvr Receiver) error {
+	if c, ok := rcvr.(Closer); ok {
+		return c.Close()
+	}
+	return flush(rcvr)
+}
+
+// logEntry logs e to rcvr, using the most complete method rcvr supports.
+// Receivers that are not EntryReceivers can't be given e's caller.
+func logEntry(rcvr Receiver, e *Entry) error {
+	switch r := rcvr.(type) {
+	case EntryReceiver:
+		return r.LogEntry(e)
+	case FieldReceiver:
+		r.Logw(e.Severity, 1, e.Message, e.Fields)
+	default:
+		rcvr.Log(e.Severity, 1, e.Message+formatFields(e.Fields))
+	}
+	return nil
+}
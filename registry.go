@@ -0,0 +1,58 @@
+package relog
+
+import (
+	"sort"
+	"sync"
+)
+
+// registry holds the receivers registered by name, for inspection by AdminHandler.
+var registry = struct {
+	sync.RWMutex
+	receivers map[string]Receiver
+}{receivers: make(map[string]Receiver)}
+
+// Register names rcvr, typically a Relay or Collector, so that AdminHandler can list and adjust it.
+// Registering a name again replaces the receiver registered under it.
+func Register(name string, rcvr Receiver) {
+	registry.Lock()
+	registry.receivers[name] = rcvr
+	registry.Unlock()
+}
+
+// registerNew registers rcvr under name unless a receiver is already registered under it,
+// reporting whether rcvr was registered.
+func registerNew(name string, rcvr Receiver) bool {
+	registry.Lock()
+	defer registry.Unlock()
+	if _, ok := registry.receivers[name]; ok {
+		return false
+	}
+	registry.receivers[name] = rcvr
+	return true
+}
+
+// Unregister removes the receiver registered under name, if any.
+func Unregister(name string) {
+	registry.Lock()
+	delete(registry.receivers, name)
+	registry.Unlock()
+}
+
+// Lookup returns the receiver registered under name, or nil if there is none.
+func Lookup(name string) Receiver {
+	registry.RLock()
+	defer registry.RUnlock()
+	return registry.receivers[name]
+}
+
+// Registered returns the names of the registered receivers, in order.
+func Registered() []string {
+	registry.RLock()
+	names := make([]string, 0, len(registry.receivers))
+	for name := range registry.receivers {
+		names = append(names, name)
+	}
+	registry.RUnlock()
+	sort.Strings(names)
+	return names
+}
@@ -4,6 +4,10 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 )
 
 // Relay forwards log messages to its receivers based on its verbosity value.
@@ -13,306 +17,712 @@ import (
 // forwards those messages to its Receivers as prioritized messages, handling os.Exit() and panic() itself, with
 // Panic[f|ln] and Fatal[f|ln] forwarded to the Receivers' Emerg[f|ln] function, and Print[f|ln]
 // forwarded to the Receivers' Notice[f|ln].
+// A Relay is safe for concurrent use; logging never blocks on changes to its settings or receivers.
 type Relay struct {
+	mu        sync.Mutex                  // serializes changes to the Relay's settings and receivers
+	cfg       atomic.Pointer[relayConfig] // replaced as a whole on every change, so each message sees one consistent set
+	calldepth int
+	fields    []Field // bound to every message forwarded by the Relay
+
+	name     string
+	parent   *Relay            // the Relay a named child inherits unset settings and receivers from
+	children map[string]*Relay // guarded by mu
+}
+
+// relayConfig holds a Relay's settings and receivers. It is never modified once stored in a Relay.
+type relayConfig struct {
 	receivers []Receiver
 	prefix    string
 	flag      int
 	verbosity int
-	calldepth int
+	stack     int // the least severe severity at which stacks are captured
+	vmodule   *vmodule
+	errs      *errorHandling
+	own       int32 // the settings set on a named child, as a mask of the own* bits
+}
+
+// Bits of relayConfig.own, recording which settings a named child has been given rather than inheriting from its parent.
+const (
+	ownVerbosity = 1 << iota
+	ownPrefix
+	ownFlags
+	ownVModule
+	ownStack
+
+	ownAll = ownVerbosity | ownPrefix | ownFlags | ownVModule | ownStack
+)
+
+// update stores a copy of the Relay's settings with set applied. The caller must hold r.mu.
+func (r *Relay) update(set func(c *relayConfig)) {
+	c := *r.cfg.Load()
+	set(&c)
+	r.cfg.Store(&c)
+}
+
+// clearOwn records that the Relay takes the setting with the given own* bit from its parent again.
+func (r *Relay) clearOwn(bit int32) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.update(func(c *relayConfig) { c.own &^= bit })
+}
+
+// inherits reports whether the Relay takes the setting with the given own* bit from its parent.
+func (r *Relay) inherits(bit int32) bool {
+	return r.parent != nil && r.cfg.Load().own&bit == 0
+}
+
+// settings returns the Relay's settings and receivers. For a named child, the settings it has not been given
+// are its parent's, and its parent's receivers precede its own, all taken from a single snapshot of each.
+func (r *Relay) settings() *relayConfig {
+	c := r.cfg.Load()
+	if r.parent == nil {
+		return c
+	}
+	p := r.parent.settings()
+	s := *c
+	if c.own&ownVerbosity == 0 {
+		s.verbosity = p.verbosity
+	}
+	if c.own&ownPrefix == 0 {
+		s.prefix = p.prefix
+	}
+	if c.own&ownFlags == 0 {
+		s.flag = p.flag
+	}
+	if c.own&ownVModule == 0 {
+		s.vmodule = p.vmodule
+	}
+	if c.own&ownStack == 0 {
+		s.stack = p.stack
+	}
+	if c.errs == nil {
+		s.errs = p.errs
+	}
+	if len(c.receivers) == 0 {
+		s.receivers = p.receivers
+	} else if len(p.receivers) > 0 {
+		s.receivers = append(p.receivers[:len(p.receivers):len(p.receivers)], c.receivers...)
+	}
+	return &s
 }
 
 // TODO: initialize this to point to sys.log
-var std Relay = Relay{
-	verbosity: LDebug,
-	calldepth: 3,
-	receivers: []Receiver{NewCollector(os.Stderr, LDebug, "", Lshortfile|LstdFlags)},
+var std = newRelay(LDebug, "", 0, 2, []Receiver{NewCollector(os.Stderr, LDebug, "", Lshortfile|LstdFlags)})
+
+// newRelay creates a new Relay with the given settings and receivers.
+func newRelay(verbosity int, prefix string, flag int, calldepth int, receivers []Receiver) *Relay {
+	r := &Relay{calldepth: calldepth}
+	r.cfg.Store(&relayConfig{receivers: receivers, prefix: prefix, flag: flag, verbosity: verbosity, stack: NoStacks})
+	return r
 }
 
 // New creates a new Relay with no receivers.
 func New(verbosity int, prefix string, flag int) *Relay {
-	return &Relay{
-		verbosity: verbosity,
-		prefix:    prefix,
-		flag:      flag,
-		calldepth: 2,
-	}
+	return newRelay(verbosity, prefix, flag, 2, nil)
 }
 
 // New creates a new Relay with a collector to StdErr
 func NewStdLog(verbosity int, prefix string, flag int) *Relay {
-	return &Relay{
-		verbosity: verbosity,
-		prefix:    prefix,
-		flag:      flag,
-		calldepth: 2,
-		receivers: []Receiver{NewCollector(os.Stderr, verbosity, "", flag)},
-	}
+	return newRelay(verbosity, prefix, flag, 2, []Receiver{NewCollector(os.Stderr, verbosity, "", flag)})
+}
+
+// With returns a new Relay which forwards messages to r's receivers, using r's settings, and attaches the given
+// alternating keys and values as Fields to every message, after any fields already bound to r.
+// Like a named child, the derived Relay follows later changes to r's settings and receivers until they are set on it;
+// it has r's name, but is not registered under it.
+func With(kv ...interface{}) *Relay { return std.With(kv...) }
+func (r *Relay) With(kv ...interface{}) *Relay {
+	d := newRelay(LDebug, "", 0, 2, nil)
+	d.name, d.parent = r.name, r
+	d.fields = joinFields(r.fields, makeFields(kv))
+	return d
 }
 
+// Fields returns the fields bound to the Relay.
+func (r *Relay) Fields() []Field { return r.fields }
+
+// Receivers returns the Relay's receivers, preceded by its parent's for a named child. The returned slice must not be modified.
+func (r *Relay) Receivers() []Receiver { return r.settings().receivers }
+
+// ownReceivers returns the receivers added to the Relay itself, rather than inherited from its parent.
+func (r *Relay) ownReceivers() []Receiver { return r.cfg.Load().receivers }
+
 // AddWriter creates a Collector and adds it to the Relay's receivers
 func (r *Relay) AddWriter(w io.Writer, verbosity int, prefix string, flag int) {
-	r.receivers = append(r.receivers, NewCollector(w, verbosity, prefix, flag))
+	r.AddReceiver(NewCollector(w, verbosity, prefix, flag))
 }
 
 // AddReceiver adds a Receiver to the Relay.
+// The Relay's receivers are copied on write, so messages being logged concurrently are unaffected.
 func (r *Relay) AddReceiver(rcvr Receiver) {
-	r.receivers = append(r.receivers, rcvr)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.update(func(c *relayConfig) { c.receivers = append(c.receivers[:len(c.receivers):len(c.receivers)], rcvr) })
+}
+
+// replace gives the Relay src's receivers and settings, including its vmodule rules, stack severity and
+// handling of write errors, in a single swap, so each message is forwarded either with all of the Relay's previous
+// receivers and settings or with all of src's. A named child keeps src's settings rather than inheriting its parent's.
+func (r *Relay) replace(src *Relay) {
+	sc := src.settings()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cfg.Store(&relayConfig{
+		receivers: sc.receivers,
+		prefix:    sc.prefix,
+		flag:      sc.flag,
+		verbosity: sc.verbosity,
+		stack:     sc.stack,
+		vmodule:   sc.vmodule,
+		errs:      sc.errs,
+		own:       ownAll,
+	})
 }
 
 // SetFlags sets the Relay's flag via a masking operation, and calls SetFlags for its Receivers with its own flags as the mask.
+// A named child passes the flags only to the receivers added to it, not to those it inherits.
 func SetFlags(flag int) { std.SetFlags(flag, NONE) }
 func (r *Relay) SetFlags(flag int, maskOp int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	f := r.Flags()
 	switch maskOp {
 	case NONE:
-		r.flag = flag
+		f = flag
 	case AND:
-		r.flag = r.flag & flag
+		f = f & flag
 	case OR:
-		r.flag = r.flag | flag
+		f = f | flag
 	case XOR:
-		r.flag = r.flag ^ flag
+		f = f ^ flag
 	case ANDNOT:
-		r.flag = r.flag &^ flag
+		f = f &^ flag
 	}
-	for i, _ := range r.receivers {
-		r.receivers[i].SetFlags(r.flag, maskOp)
+	r.update(func(c *relayConfig) { c.flag, c.own = f, c.own|ownFlags })
+	for _, rcvr := range r.ownReceivers() {
+		rcvr.SetFlags(f, maskOp)
 	}
 }
 
 // Flags returns the output flags for the Relay
-func Flags() int            { return std.Flags() }
-func (r *Relay) Flags() int { return r.flag }
+func Flags() int { return std.Flags() }
+func (r *Relay) Flags() int {
+	if r.inherits(ownFlags) {
+		return r.parent.Flags()
+	}
+	return r.cfg.Load().flag
+}
 
 // SetPrefix sets the Relay's prefix which is prepended to log statements.
 func SetPrefix(prefix string) { std.SetPrefix(prefix) }
 func (r *Relay) SetPrefix(prefix string) {
-	r.prefix = prefix
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.update(func(c *relayConfig) { c.prefix, c.own = prefix, c.own|ownPrefix })
 }
 
 // Prefix returns the log prefix for the Relay
-func Prefix() string            { return std.Prefix() }
-func (r *Relay) Prefix() string { return r.prefix }
+func Prefix() string { return std.Prefix() }
+func (r *Relay) Prefix() string {
+	if r.inherits(ownPrefix) {
+		return r.parent.Prefix()
+	}
+	return r.cfg.Load().prefix
+}
 
 // SetOutput sets the standard Relay's receiver output.
 func SetOutput(w io.Writer) {
-	std.receivers[0].SetOutput(w)
+	std.Receivers()[0].SetOutput(w)
 }
 
 // SetOutput is a null function for interface compatibility; set Collector outputs directly instead.
 func (r *Relay) SetOutput(w io.Writer) {}
 
 // Output writes the output for a logging event. Only provided for compatibility with standard log package.
-func Output(calldepth int, s string) { std.Output(calldepth, s) }
-func (r *Relay) Output(calldepth int, s string) {
-	for i, _ := range r.receivers {
-		r.receivers[i].Output(calldepth, s)
+// It returns the first error encountered among the Relay's receivers.
+func Output(calldepth int, s string) error { return std.Output(calldepth, s) }
+func (r *Relay) Output(calldepth int, s string) error {
+	c := r.settings()
+	var err error
+	for _, rcvr := range c.receivers {
+		if e := rcvr.Output(calldepth, s); e != nil {
+			if err == nil {
+				err = e
+			}
+			if c.errs != nil {
+				c.errs.handle(rcvr, e, &Entry{Time: time.Now(), Severity: LNotice, Message: s})
+			}
+		}
 	}
+	return err
 }
 
 // SetVerbosity sets the Relay's verbosity.
 func SetVerbosity(verbosity int) { std.SetVerbosity(verbosity) }
 func (r *Relay) SetVerbosity(verbosity int) {
-	r.verbosity = verbosity
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.update(func(c *relayConfig) { c.verbosity, c.own = verbosity, c.own|ownVerbosity })
+}
+
+// Verbosity returns the Relay's verbosity.
+func Verbosity() int { return std.Verbosity() }
+func (r *Relay) Verbosity() int {
+	if r.inherits(ownVerbosity) {
+		return r.parent.Verbosity()
+	}
+	return r.cfg.Load().verbosity
+}
+
+// SetStackSeverity sets the Relay to capture the stack of the logging goroutine for messages at severity
+// or more severe, e.g. LError, passing it to receivers with the message; NoStacks, the default, captures none.
+// Entries passed to LogEntry keep the stack they were given.
+func SetStackSeverity(severity int) { std.SetStackSeverity(severity) }
+func (r *Relay) SetStackSeverity(severity int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.update(func(c *relayConfig) { c.stack, c.own = severity, c.own|ownStack })
+}
+
+// StackSeverity returns the least severe severity at which the Relay captures stacks.
+func StackSeverity() int { return std.StackSeverity() }
+func (r *Relay) StackSeverity() int {
+	if r.inherits(ownStack) {
+		return r.parent.StackSeverity()
+	}
+	return r.cfg.Load().stack
+}
+
+// stacks reports whether stacks are captured for messages at severity under the settings.
+func (c *relayConfig) stacks(severity int) bool {
+	return severity <= c.stack
+}
+
+// direct reports whether messages at severity can be passed straight to each receiver's Log, Logf or Logln
+// under the settings, without bound fields, error handling or a stack.
+func (r *Relay) direct(c *relayConfig, severity int) bool {
+	return len(r.fields) == 0 && c.errs == nil && !c.stacks(severity)
 }
 
 // Log forwards messages to the each receiver's Log function.
 func (r *Relay) Log(severity int, calldepth int, v ...interface{}) {
-	if r.verbosity < severity {
+	c := r.settings()
+	if !c.enabled(severity, calldepth) {
 		return
 	}
-	v = append([]interface{}{r.prefix}, v...)
+	v = append([]interface{}{c.prefix}, v...)
 	calldepth++ // increment for this frame
-	for i, _ := range r.receivers {
-		r.receivers[i].Log(severity, calldepth, v...)
+	if !r.direct(c, severity) {
+		r.forward(c, severity, calldepth, "", fmt.Sprint(v...), r.fields)
+		return
+	}
+	for _, rcvr := range c.receivers {
+		rcvr.Log(severity, calldepth, v...)
 	}
 }
 
 // Logf forwards messages to the each receiver's Logf function.
 func (r *Relay) Logf(severity int, calldepth int, format string, v ...interface{}) {
-	if r.verbosity < severity {
+	c := r.settings()
+	if !c.enabled(severity, calldepth) {
 		return
 	}
-	if r.prefix != "" {
+	if c.prefix != "" {
 		format = "%s " + format
-		v = append([]interface{}{r.prefix}, v...)
+		v = append([]interface{}{c.prefix}, v...)
 	}
 	calldepth++ // increment for this frame
-	for i, _ := range r.receivers {
-		r.receivers[i].Logf(severity, calldepth, format, v...)
+	if !r.direct(c, severity) {
+		r.forward(c, severity, calldepth, format, fmt.Sprintf(format, v...), r.fields)
+		return
+	}
+	for _, rcvr := range c.receivers {
+		rcvr.Logf(severity, calldepth, format, v...)
 	}
 }
 
 // Logln forwards messages to the each receiver's Logln function.
 func (r *Relay) Logln(severity int, calldepth int, v ...interface{}) {
-	if r.verbosity < severity {
+	c := r.settings()
+	if !c.enabled(severity, calldepth) {
 		return
 	}
-	if r.prefix != "" {
-		v = append([]interface{}{r.prefix}, v...)
+	if c.prefix != "" {
+		v = append([]interface{}{c.prefix}, v...)
 	}
 	calldepth++ // increment for this frame
-	for i, _ := range r.receivers {
-		r.receivers[i].Logln(severity, calldepth, v...)
+	if !r.direct(c, severity) {
+		r.forward(c, severity, calldepth, "", strings.TrimSuffix(fmt.Sprintln(v...), "\n"), r.fields)
+		return
 	}
+	for _, rcvr := range c.receivers {
+		rcvr.Logln(severity, calldepth, v...)
+	}
+}
+
+// Logw forwards msg, with the Relay's bound fields followed by fields, to each receiver.
+// Receivers that are not FieldReceivers get the fields rendered into the message via Log.
+func (r *Relay) Logw(severity int, calldepth int, msg string, fields []Field) {
+	r.logwf(severity, calldepth+1, "", msg, fields)
 }
 
-// Fatal is equivalent to a call to r.Emerg followed by a call to os.Exit(1).
-func Fatal(v ...interface{}) { std.Fatal(v...) }
+// logwf is Logw for msg formatted from format, which is passed on to receivers that take it, such as SamplingReceiver.
+func (r *Relay) logwf(severity int, calldepth int, format string, msg string, fields []Field) {
+	c := r.settings()
+	if !c.enabled(severity, calldepth) {
+		return
+	}
+	if c.prefix != "" {
+		msg = c.prefix + " " + msg
+		if format != "" {
+			format = "%s " + format
+		}
+	}
+	calldepth++ // increment for this frame
+	r.forward(c, severity, calldepth, format, msg, joinFields(r.fields, fields))
+}
+
+// LogEntry forwards e, with the Relay's prefix and bound fields applied, to each receiver.
+// It returns the first error encountered among the Relay's receivers.
+func (r *Relay) LogEntry(e *Entry) error {
+	c := r.settings()
+	if !c.entryEnabled(e) {
+		return nil
+	}
+	entry := *e
+	if c.prefix != "" {
+		entry.Message = c.prefix + " " + entry.Message
+	}
+	entry.Fields = joinFields(r.fields, e.Fields)
+	var err error
+	for _, rcvr := range c.receivers {
+		if e := logEntry(rcvr, &entry); e != nil {
+			if err == nil {
+				err = e
+			}
+			if c.errs != nil {
+				c.errs.handle(rcvr, e, &entry)
+			}
+		}
+	}
+	return err
+}
+
+// forward sends msg, formatted from format if that is set, and fields to each receiver in the settings c,
+// via Logw where the receiver supports it.
+// If the Relay handles write errors or captures a stack for the message, EntryReceivers are passed an Entry
+// via LogEntry instead, so that errors are returned and the stack is kept apart from the fields.
+// Other receivers get the stack as the field "stack".
+func (r *Relay) forward(c *relayConfig, severity int, calldepth int, format string, msg string, fields []Field) {
+	var e *Entry
+	var st string
+	if c.stacks(severity) {
+		st = stack(calldepth)
+	}
+	if c.errs != nil || st != "" {
+		e = &Entry{Time: time.Now(), Severity: severity, Message: msg, Fields: fields, Stack: st, format: format}
+		e.File, e.Line = caller(calldepth, Llongfile)
+	}
+	calldepth++ // increment for this frame
+	fields = stackField(fields, st)
+	for _, rcvr := range c.receivers {
+		if er, ok := rcvr.(EntryReceiver); ok && e != nil {
+			if err := er.LogEntry(e); err != nil && c.errs != nil {
+				c.errs.handle(rcvr, err, e)
+			}
+			continue
+		}
+		if fr, ok := rcvr.(formatReceiver); ok && format != "" {
+			fr.logwf(severity, calldepth, format, msg, fields)
+		} else if fr, ok := rcvr.(FieldReceiver); ok {
+			fr.Logw(severity, calldepth, msg, fields)
+		} else {
+			rcvr.Log(severity, calldepth, msg+formatFields(fields))
+		}
+	}
+}
+
+// Flush flushes the Relay's receivers that implement Flusher, including nested Relays,
+// returning the first error encountered.
+func Flush() error { return std.Flush() }
+func (r *Relay) Flush() error {
+	var err error
+	for _, rcvr := range r.Receivers() {
+		if e := flush(rcvr); e != nil && err == nil {
+			err = e
+		}
+	}
+	return err
+}
+
+// Close closes the Relay's receivers that implement Closer, including nested Relays, and flushes the rest,
+// returning the first error encountered. A named child closes only the receivers added to it.
+// Messages logged after Close may be lost.
+func (r *Relay) Close() error {
+	var err error
+	for _, rcvr := range r.ownReceivers() {
+		if e := closeReceiver(rcvr); e != nil && err == nil {
+			err = e
+		}
+	}
+	return err
+}
+
+// exitFlushTimeout bounds the time Fatal, Panic and Exit wait for receivers to flush.
+const exitFlushTimeout = 5 * time.Second
+
+// osExit is os.Exit, replaced in tests.
+var osExit = os.Exit
+
+// flushWithin flushes the Relay, waiting at most d for it to finish.
+func (r *Relay) flushWithin(d time.Duration) {
+	done := make(chan struct{})
+	go func() {
+		r.Flush()
+		close(done)
+	}()
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-done:
+	case <-timer.C:
+	}
+}
+
+// Exit flushes the standard Relay's receivers, waiting a few seconds at most, then exits the program with code.
+func Exit(code int) {
+	std.flushWithin(exitFlushTimeout)
+	osExit(code)
+}
+
+// exit flushes the Relay's receivers, waiting a few seconds at most, then exits the program with code 1.
+func (r *Relay) exit() {
+	r.flushWithin(exitFlushTimeout)
+	osExit(1)
+}
+
+// panic flushes the Relay's receivers, waiting a few seconds at most, then panics with msg.
+func (r *Relay) panic(msg string) {
+	r.flushWithin(exitFlushTimeout)
+	panic(msg)
+}
+
+// Fatal is equivalent to a call to r.Emerg followed by a call to os.Exit(1), after flushing the receivers.
+func Fatal(v ...interface{}) { std.Log(LEmerg, 2, v...); std.exit() }
 func (r *Relay) Fatal(v ...interface{}) {
 	r.Log(LEmerg, r.calldepth, v...)
-	os.Exit(1)
+	r.exit()
 }
 
-// Fatalf is equivalent to a call to r.Emergf followed by a call to os.Exit(1).
-func Fatalf(format string, v ...interface{}) { std.Fatalf(format, v...) }
+// Fatalf is equivalent to a call to r.Emergf followed by a call to os.Exit(1), after flushing the receivers.
+func Fatalf(format string, v ...interface{}) { std.Logf(LEmerg, 2, format, v...); std.exit() }
 func (r *Relay) Fatalf(format string, v ...interface{}) {
 	r.Logf(LEmerg, r.calldepth, format, v...)
-	os.Exit(1)
+	r.exit()
 }
 
-// Fatalln is equivalent to a call to r.Emergln followed by a call to os.Exit(1).
-func Fatalln(v ...interface{}) { std.Fatalln(v...) }
+// Fatalln is equivalent to a call to r.Emergln followed by a call to os.Exit(1), after flushing the receivers.
+func Fatalln(v ...interface{}) { std.Logln(LEmerg, 2, v...); std.exit() }
 func (r *Relay) Fatalln(v ...interface{}) {
 	r.Logln(LEmerg, r.calldepth, v...)
-	os.Exit(1)
+	r.exit()
 }
 
-// Panic is equivalent to a call to r.Emerg followed by a call to panic().
-func Panic(v ...interface{}) { std.Panic(v...) }
+// Panic is equivalent to a call to r.Emerg followed by a call to panic(), after flushing the receivers.
+func Panic(v ...interface{}) { std.Log(LEmerg, 2, v...); std.panic(std.sprint(v...)) }
 func (r *Relay) Panic(v ...interface{}) {
 	r.Log(LEmerg, r.calldepth, v...)
-	v = append([]interface{}{r.prefix}, v...)
-	panic(fmt.Sprint(v...))
+	r.panic(r.sprint(v...))
 }
 
-// Panicf is equivalent to a call to r.Logf at severity Emerg followed by a call to panic().
-func Panicf(format string, v ...interface{}) { std.Panicf(format, v...) }
+// Panicf is equivalent to a call to r.Logf at severity Emerg followed by a call to panic(), after flushing the receivers.
+func Panicf(format string, v ...interface{}) {
+	std.Logf(LEmerg, 2, format, v...)
+	std.panic(std.sprintf(format, v...))
+}
 func (r *Relay) Panicf(format string, v ...interface{}) {
 	r.Logf(LEmerg, r.calldepth, format, v...)
-	msg := fmt.Sprintf(format, v...)
-	if r.prefix != "" {
-		panic(fmt.Sprintf("%s %s", r.prefix, msg))
-	} else {
-		panic(msg)
-	}
+	r.panic(r.sprintf(format, v...))
 }
 
-// Panicln is equivalent to a call to r.Emergln followed by a call to panic().
-func Panicln(v ...interface{}) { std.Panicln(v...) }
+// Panicln is equivalent to a call to r.Emergln followed by a call to panic(), after flushing the receivers.
+func Panicln(v ...interface{}) { std.Logln(LEmerg, 2, v...); std.panic(std.sprintln(v...)) }
 func (r *Relay) Panicln(v ...interface{}) {
 	r.Logln(LEmerg, r.calldepth, v...)
-	v = append([]interface{}{r.prefix}, v...)
-	panic(fmt.Sprintln(v...))
+	r.panic(r.sprintln(v...))
+}
+
+// sprint formats v as Panic's message, preceded by the Relay's prefix.
+func (r *Relay) sprint(v ...interface{}) string {
+	return fmt.Sprint(append([]interface{}{r.Prefix()}, v...)...)
+}
+
+// sprintf formats v according to format as Panicf's message, preceded by the Relay's prefix if it has one.
+func (r *Relay) sprintf(format string, v ...interface{}) string {
+	msg := fmt.Sprintf(format, v...)
+	if prefix := r.Prefix(); prefix != "" {
+		return fmt.Sprintf("%s %s", prefix, msg)
+	}
+	return msg
+}
+
+// sprintln formats v as Panicln's message, preceded by the Relay's prefix.
+func (r *Relay) sprintln(v ...interface{}) string {
+	return fmt.Sprintln(append([]interface{}{r.Prefix()}, v...)...)
 }
 
 // Print is equivalent to a call to r.Log at severity Notice.
-func Print(v ...interface{}) { std.Print(v...) }
+func Print(v ...interface{}) { std.Log(LNotice, 2, v...) }
 func (r *Relay) Print(v ...interface{}) {
 	r.Log(LNotice, r.calldepth, v...)
 }
 
 // Printf is equivalent to a call to r.Logf at severity Notice.
-func Printf(format string, v ...interface{})            { std.Printf(format, v...) }
+func Printf(format string, v ...interface{})            { std.Logf(LNotice, 2, format, v...) }
 func (r *Relay) Printf(format string, v ...interface{}) { r.Logf(LNotice, r.calldepth, format, v...) }
 
 // Println is equivalent to a call to r.Logln at severity Notice.
-func Println(v ...interface{})            { std.Println(v...) }
+func Println(v ...interface{})            { std.Logln(LNotice, 2, v...) }
 func (r *Relay) Println(v ...interface{}) { r.Logln(LNotice, r.calldepth, v...) }
 
 // Emerg calls Log with severity Emerg.
-func Emerg(v ...interface{})            { std.Emerg(v...) }
+func Emerg(v ...interface{})            { std.Log(LEmerg, 2, v...) }
 func (r *Relay) Emerg(v ...interface{}) { r.Log(LEmerg, r.calldepth, v...) }
 
 // Emergf calls Logf with severity Emerg.
-func Emergf(format string, v ...interface{})            { std.Emergf(format, v...) }
+func Emergf(format string, v ...interface{})            { std.Logf(LEmerg, 2, format, v...) }
 func (r *Relay) Emergf(format string, v ...interface{}) { r.Logf(LEmerg, r.calldepth, format, v...) }
 
 // Emergln calls Logln with severity Emerg.
-func Emergln(v ...interface{})            { std.Emergln(v...) }
+func Emergln(v ...interface{})            { std.Logln(LEmerg, 2, v...) }
 func (r *Relay) Emergln(v ...interface{}) { r.Logln(LEmerg, r.calldepth, v...) }
 
+// Emergw calls Logw with severity Emerg and the given alternating keys and values as fields.
+func Emergw(msg string, kv ...interface{}) { std.Logw(LEmerg, 2, msg, makeFields(kv)) }
+func (r *Relay) Emergw(msg string, kv ...interface{}) {
+	r.Logw(LEmerg, r.calldepth, msg, makeFields(kv))
+}
+
 // Alert calls Log with severity Alert.
-func Alert(v ...interface{})            { std.Alert(v...) }
+func Alert(v ...interface{})            { std.Log(LAlert, 2, v...) }
 func (r *Relay) Alert(v ...interface{}) { r.Log(LAlert, r.calldepth, v...) }
 
 // Alertf calls Logf with severity Alert.
-func Alertf(format string, v ...interface{})            { std.Alertf(format, v...) }
+func Alertf(format string, v ...interface{})            { std.Logf(LAlert, 2, format, v...) }
 func (r *Relay) Alertf(format string, v ...interface{}) { r.Logf(LAlert, r.calldepth, format, v...) }
 
 // Alertln calls Logln with severity Alert.
-func Alertln(v ...interface{})            { std.Alertln(v...) }
+func Alertln(v ...interface{})            { std.Logln(LAlert, 2, v...) }
 func (r *Relay) Alertln(v ...interface{}) { r.Logln(LAlert, r.calldepth, v...) }
 
+// Alertw calls Logw with severity Alert and the given alternating keys and values as fields.
+func Alertw(msg string, kv ...interface{}) { std.Logw(LAlert, 2, msg, makeFields(kv)) }
+func (r *Relay) Alertw(msg string, kv ...interface{}) {
+	r.Logw(LAlert, r.calldepth, msg, makeFields(kv))
+}
+
 // Critical calls Log with severity Critical.
-func Critical(v ...interface{})            { std.Critical(v...) }
+func Critical(v ...interface{})            { std.Log(LCritical, 2, v...) }
 func (r *Relay) Critical(v ...interface{}) { r.Log(LCritical, r.calldepth, v...) }
 
 // Criticalf calls Logf with severity Critical.
-func Criticalf(format string, v ...interface{}) { std.Criticalf(format, v...) }
+func Criticalf(format string, v ...interface{}) { std.Logf(LCritical, 2, format, v...) }
 func (r *Relay) Criticalf(format string, v ...interface{}) {
 	r.Logf(LCritical, r.calldepth, format, v...)
 }
 
 // Criticalln calls Logln with severity Critical.
-func Criticalln(v ...interface{})            { std.Criticalln(v...) }
+func Criticalln(v ...interface{})            { std.Logln(LCritical, 2, v...) }
 func (r *Relay) Criticalln(v ...interface{}) { r.Logln(LCritical, r.calldepth, v...) }
 
+// Criticalw calls Logw with severity Critical and the given alternating keys and values as fields.
+func Criticalw(msg string, kv ...interface{}) { std.Logw(LCritical, 2, msg, makeFields(kv)) }
+func (r *Relay) Criticalw(msg string, kv ...interface{}) {
+	r.Logw(LCritical, r.calldepth, msg, makeFields(kv))
+}
+
 // Error calls Log with severity Error.
-func Error(v ...interface{})            { std.Error(v...) }
+func Error(v ...interface{})            { std.Log(LError, 2, v...) }
 func (r *Relay) Error(v ...interface{}) { r.Log(LError, r.calldepth, v...) }
 
 // Errorf calls Logf with severity Error.
-func Errorf(format string, v ...interface{})            { std.Errorf(format, v...) }
+func Errorf(format string, v ...interface{})            { std.Logf(LError, 2, format, v...) }
 func (r *Relay) Errorf(format string, v ...interface{}) { r.Logf(LError, r.calldepth, format, v...) }
 
 // Errorln calls Logln with severity Error.
-func Errorln(v ...interface{})            { std.Errorln(v...) }
+func Errorln(v ...interface{})            { std.Logln(LError, 2, v...) }
 func (r *Relay) Errorln(v ...interface{}) { r.Logln(LError, r.calldepth, v...) }
 
+// Errorw calls Logw with severity Error and the given alternating keys and values as fields.
+func Errorw(msg string, kv ...interface{}) { std.Logw(LError, 2, msg, makeFields(kv)) }
+func (r *Relay) Errorw(msg string, kv ...interface{}) {
+	r.Logw(LError, r.calldepth, msg, makeFields(kv))
+}
+
 // Warn calls Log with severity Warn.
-func Warn(v ...interface{})            { std.Warn(v...) }
+func Warn(v ...interface{})            { std.Log(LWarn, 2, v...) }
 func (r *Relay) Warn(v ...interface{}) { r.Log(LWarn, r.calldepth, v...) }
 
 // Warnf calls Logf with severity Warn.
-func Warnf(format string, v ...interface{})            { std.Warnf(format, v...) }
+func Warnf(format string, v ...interface{})            { std.Logf(LWarn, 2, format, v...) }
 func (r *Relay) Warnf(format string, v ...interface{}) { r.Logf(LWarn, r.calldepth, format, v...) }
 
 // Warnln calls Logln with severity Warn.
-func Warnln(v ...interface{})            { std.Warnln(v...) }
+func Warnln(v ...interface{})            { std.Logln(LWarn, 2, v...) }
 func (r *Relay) Warnln(v ...interface{}) { r.Logln(LWarn, r.calldepth, v...) }
 
+// Warnw calls Logw with severity Warn and the given alternating keys and values as fields.
+func Warnw(msg string, kv ...interface{})            { std.Logw(LWarn, 2, msg, makeFields(kv)) }
+func (r *Relay) Warnw(msg string, kv ...interface{}) { r.Logw(LWarn, r.calldepth, msg, makeFields(kv)) }
+
 // Notice calls Log with severity Notice.
-func Notice(v ...interface{})            { std.Notice(v...) }
+func Notice(v ...interface{})            { std.Log(LNotice, 2, v...) }
 func (r *Relay) Notice(v ...interface{}) { r.Log(LNotice, r.calldepth, v...) }
 
 // Noticef calls Logf with severity Notice.
-func Noticef(format string, v ...interface{})            { std.Noticef(format, v...) }
+func Noticef(format string, v ...interface{})            { std.Logf(LNotice, 2, format, v...) }
 func (r *Relay) Noticef(format string, v ...interface{}) { r.Logf(LNotice, r.calldepth, format, v...) }
 
 // Noticeln calls Logln with severity Notice.
-func Noticeln(v ...interface{})            { std.Noticeln(v...) }
+func Noticeln(v ...interface{})            { std.Logln(LNotice, 2, v...) }
 func (r *Relay) Noticeln(v ...interface{}) { r.Logln(LNotice, r.calldepth, v...) }
 
+// Noticew calls Logw with severity Notice and the given alternating keys and values as fields.
+func Noticew(msg string, kv ...interface{}) { std.Logw(LNotice, 2, msg, makeFields(kv)) }
+func (r *Relay) Noticew(msg string, kv ...interface{}) {
+	r.Logw(LNotice, r.calldepth, msg, makeFields(kv))
+}
+
 // Info calls Log with severity Info.
-func Info(v ...interface{})            { std.Info(v...) }
+func Info(v ...interface{})            { std.Log(LInfo, 2, v...) }
 func (r *Relay) Info(v ...interface{}) { r.Log(LInfo, r.calldepth, v...) }
 
 // Infof calls Logf with severity Info.
-func Infof(format string, v ...interface{})            { std.Infof(format, v...) }
+func Infof(format string, v ...interface{})            { std.Logf(LInfo, 2, format, v...) }
 func (r *Relay) Infof(format string, v ...interface{}) { r.Logf(LInfo, r.calldepth, format, v...) }
 
 // Infoln calls Logln with severity Info.
-func Infoln(v ...interface{})            { std.Infoln(v...) }
+func Infoln(v ...interface{})            { std.Logln(LInfo, 2, v...) }
 func (r *Relay) Infoln(v ...interface{}) { r.Logln(LInfo, r.calldepth, v...) }
 
+// Infow calls Logw with severity Info and the given alternating keys and values as fields.
+func Infow(msg string, kv ...interface{})            { std.Logw(LInfo, 2, msg, makeFields(kv)) }
+func (r *Relay) Infow(msg string, kv ...interface{}) { r.Logw(LInfo, r.calldepth, msg, makeFields(kv)) }
+
 // Debug calls Log with severity Debug.
-func Debug(v ...interface{})            { std.Debug(v...) }
+func Debug(v ...interface{})            { std.Log(LDebug, 2, v...) }
 func (r *Relay) Debug(v ...interface{}) { r.Log(LDebug, r.calldepth, v...) }
 
 // Debugf calls Logf with severity Debug.
-func Debugf(format string, v ...interface{})            { std.Debugf(format, v...) }
+func Debugf(format string, v ...interface{})            { std.Logf(LDebug, 2, format, v...) }
 func (r *Relay) Debugf(format string, v ...interface{}) { r.Logf(LDebug, r.calldepth, format, v...) }
 
 // Debugln calls Logln with severity Debug.
-func Debugln(v ...interface{})            { std.Debugln(v...) }
+func Debugln(v ...interface{})            { std.Logln(LDebug, 2, v...) }
 func (r *Relay) Debugln(v ...interface{}) { r.Logln(LDebug, r.calldepth, v...) }
+
+// Debugw calls Logw with severity Debug and the given alternating keys and values as fields.
+func Debugw(msg string, kv ...interface{}) { std.Logw(LDebug, 2, msg, makeFields(kv)) }
+func (r *Relay) Debugw(msg string, kv ...interface{}) {
+	r.Logw(LDebug, r.calldepth, msg, makeFields(kv))
+}
@@ -2,7 +2,9 @@ package relog
 
 import (
 	"bytes"
+	"io"
 	"strings"
+	"sync"
 	"testing"
 )
 
@@ -61,3 +63,88 @@ func TestRelayPrint(t *testing.T) {
 		}
 	}
 }
+
+func TestRelayFields(t *testing.T) {
+	var output bytes.Buffer
+	relay := New(LDebug, "", 0)
+	relay.AddWriter(&output, LDebug, "", 0)
+	derived := relay.With("request", "abc", "user", 42)
+
+	derived.Infow("relay", "took", "1s")
+	if exp, result := "[INFO] relay request=abc user=42 took=1s\n", output.String(); result != exp {
+		t.Errorf("Relay Infow messages didn't match\nEXP: %s^\nGOT: %s^", exp, result)
+	}
+
+	output.Reset()
+	derived.Infof("%s", "relay")
+	if exp, result := "[INFO] relay request=abc user=42\n", output.String(); result != exp {
+		t.Errorf("Relay Infof messages didn't match\nEXP: %s^\nGOT: %s^", exp, result)
+	}
+
+	output.Reset()
+	relay.Info("relay")
+	if exp, result := "[INFO] relay\n", output.String(); result != exp {
+		t.Errorf("Relay Info messages carried derived fields\nEXP: %s^\nGOT: %s^", exp, result)
+	}
+
+	var added bytes.Buffer
+	output.Reset()
+	relay.SetVerbosity(LWarn)
+	relay.AddWriter(&added, LDebug, "", 0)
+	derived.Info("hidden")
+	derived.Warn("relay")
+	if exp, result := "[WARNING] relay request=abc user=42\n", output.String()+added.String(); result != exp+exp {
+		t.Errorf("Derived Relay didn't follow its parent's settings\nEXP: %s^\nGOT: %s^", exp+exp, result)
+	}
+
+	output.Reset()
+	relay.Warnw("relay", "msg", "two words", "odd")
+	if exp, result := "[WARNING] relay msg=\"two words\" !BADKEY=odd\n", output.String(); result != exp {
+		t.Errorf("Relay Warnw messages didn't match\nEXP: %s^\nGOT: %s^", exp, result)
+	}
+}
+
+func TestNestedRelayFields(t *testing.T) {
+	var output bytes.Buffer
+	inner := New(LDebug, "", 0)
+	inner.AddWriter(&output, LDebug, "", Lshortfile)
+	outer := New(LDebug, "", 0)
+	outer.AddReceiver(inner.With("inner", true))
+
+	outer.With("outer", 1).Errorw("relay")
+	result := output.String()
+	if exp := "[ERROR] relay inner=true outer=1\n"; !strings.HasSuffix(result, exp) {
+		t.Errorf("Nested Relay Errorw messages didn't match\nEXP: %s^\nGOT: %s^", exp, result)
+	} else if !strings.HasPrefix(result, "relay_test.go:") {
+		t.Errorf("Nested Relay Errorw messages have wrong caller\nGOT: %s^", result)
+	}
+}
+
+func TestRelayConcurrency(t *testing.T) {
+	relay := New(LDebug, "", 0)
+	relay.AddWriter(io.Discard, LDebug, "", 0)
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				relay.Infow("relay", "j", j)
+				relay.Debugf("%d", j)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 10; j++ {
+				relay.AddWriter(io.Discard, LDebug, "", 0)
+				relay.SetVerbosity(LDebug - j%2)
+				relay.SetPrefix("relay")
+				relay.SetFlags(Lshortfile, OR)
+			}
+		}()
+	}
+	wg.Wait()
+	if n := len(relay.Receivers()); n != 41 {
+		t.Errorf("Relay has wrong number of receivers EXP: %d GOT: %d", 41, n)
+	}
+}
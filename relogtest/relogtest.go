@@ -0,0 +1,254 @@
+/*
+Package relogtest provides a relog Receiver which records log entries for tests to make assertions about,
+and a Receiver which writes log entries to a test's log, so that they are reported with the test that produced them.
+
+	rec := relogtest.NewRecorder()
+	relay := relog.New(relog.LDebug, "", 0)
+	relay.AddReceiver(rec)
+	relay.AddReceiver(relogtest.NewTestReceiver(t))
+	codeUnderTest(relay)
+	rec.AssertLogged(t, relog.LError, "connection refused")
+*/
+package relogtest
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gpitfield/relog"
+)
+
+// Recorder records the entries it receives, with their callers and fields.
+// Recorder implements relog.Receiver, relog.FieldReceiver and relog.EntryReceiver, and is safe for concurrent use.
+type Recorder struct {
+	verbosity atomic.Int32
+
+	mu      sync.Mutex
+	entries []relog.Entry
+}
+
+// NewRecorder creates a new Recorder which records entries of every severity.
+func NewRecorder() *Recorder {
+	r := &Recorder{}
+	r.verbosity.Store(relog.LDebug)
+	return r
+}
+
+// record resolves the caller calldepth frames above record and records an entry for msg and fields.
+func (r *Recorder) record(severity int, calldepth int, msg string, fields []relog.Field) {
+	if int(r.verbosity.Load()) < severity {
+		return
+	}
+	e := relog.Entry{Time: time.Now(), Severity: severity, Message: strings.TrimSuffix(msg, "\n"), Fields: fields}
+	if _, file, line, ok := runtime.Caller(calldepth); ok {
+		e.File, e.Line = file, line
+	}
+	r.mu.Lock()
+	r.entries = append(r.entries, e)
+	r.mu.Unlock()
+}
+
+// Entries returns the entries recorded, oldest first.
+func (r *Recorder) Entries() []relog.Entry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]relog.Entry(nil), r.entries...)
+}
+
+// Reset discards the entries recorded.
+func (r *Recorder) Reset() {
+	r.mu.Lock()
+	r.entries = nil
+	r.mu.Unlock()
+}
+
+// Count returns the number of entries recorded at severity whose messages contain substr.
+func (r *Recorder) Count(severity int, substr string) int {
+	n := 0
+	for _, e := range r.Entries() {
+		if e.Severity == severity && strings.Contains(e.Message, substr) {
+			n++
+		}
+	}
+	return n
+}
+
+// AssertLogged reports an error to t, and returns false, unless an entry was recorded at severity whose message contains substr.
+func (r *Recorder) AssertLogged(t testing.TB, severity int, substr string) bool {
+	t.Helper()
+	if r.Count(severity, substr) == 0 {
+		t.Errorf("relogtest: no entry at severity %s containing %q\n%s", relog.Severity(severity), substr, r)
+		return false
+	}
+	return true
+}
+
+// AssertNotLogged reports an error to t, and returns false, if an entry was recorded at severity whose message contains substr.
+func (r *Recorder) AssertNotLogged(t testing.TB, severity int, substr string) bool {
+	t.Helper()
+	if n := r.Count(severity, substr); n > 0 {
+		t.Errorf("relogtest: %d entries at severity %s containing %q\n%s", n, relog.Severity(severity), substr, r)
+		return false
+	}
+	return true
+}
+
+// RequireCount stops the test, via t.Fatalf, unless exactly n entries were recorded at severity whose messages contain substr.
+func (r *Recorder) RequireCount(t testing.TB, severity int, substr string, n int) {
+	t.Helper()
+	if count := r.Count(severity, substr); count != n {
+		t.Fatalf("relogtest: %d entries at severity %s containing %q, want %d\n%s", count, relog.Severity(severity), substr, n, r)
+	}
+}
+
+// String returns the entries recorded, one per line, as laid out by relog.TextFormatter with short file names.
+func (r *Recorder) String() string {
+	var b bytes.Buffer
+	for _, e := range r.Entries() {
+		format(&b, e)
+	}
+	return b.String()
+}
+
+// format writes e to w as laid out by relog.TextFormatter with short file names.
+func format(w io.Writer, e relog.Entry) {
+	if i := strings.LastIndex(e.File, "/"); i >= 0 {
+		e.File = e.File[i+1:]
+	}
+	e.Flag = relog.Lshortfile
+	relog.TextFormatter{}.Format(w, &e)
+}
+
+// SetOutput is a null function for interface compatibility.
+func (r *Recorder) SetOutput(w io.Writer) {}
+
+// SetFlags is a null function for interface compatibility; callers are always recorded.
+func (r *Recorder) SetFlags(flag int, maskOp int) {}
+
+// SetPrefix is a null function for interface compatibility.
+func (r *Recorder) SetPrefix(prefix string) {}
+
+// SetVerbosity sets the Recorder's verbosity. Messages of lower priority than the verbosity are not recorded.
+func (r *Recorder) SetVerbosity(verbosity int) { r.verbosity.Store(int32(verbosity)) }
+
+// Output records s as the message of an entry at severity Notice.
+func (r *Recorder) Output(calldepth int, s string) error {
+	r.record(relog.LNotice, calldepth+1, s, nil)
+	return nil
+}
+
+// Log records the message.
+func (r *Recorder) Log(severity int, calldepth int, v ...interface{}) {
+	r.record(severity, calldepth+1, fmt.Sprint(v...), nil)
+}
+
+// Logf records the message.
+func (r *Recorder) Logf(severity int, calldepth int, format string, v ...interface{}) {
+	r.record(severity, calldepth+1, fmt.Sprintf(format, v...), nil)
+}
+
+// Logln records the message.
+func (r *Recorder) Logln(severity int, calldepth int, v ...interface{}) {
+	r.record(severity, calldepth+1, fmt.Sprintln(v...), nil)
+}
+
+// Logw records msg and fields.
+func (r *Recorder) Logw(severity int, calldepth int, msg string, fields []relog.Field) {
+	r.record(severity, calldepth+1, msg, fields)
+}
+
+// LogEntry records e.
+func (r *Recorder) LogEntry(e *relog.Entry) error {
+	if int(r.verbosity.Load()) >= e.Severity {
+		entry := *e
+		entry.Message = strings.TrimSuffix(entry.Message, "\n")
+		r.mu.Lock()
+		r.entries = append(r.entries, entry)
+		r.mu.Unlock()
+	}
+	return nil
+}
+
+// TestReceiver writes the entries it receives to a test's log with t.Log, laid out by relog.TextFormatter
+// with short file names, so that they are reported with the test. Each entry is shown with the file and line
+// it was logged from, as the location t.Log reports is within relogtest. Entries received after the test
+// has finished are discarded.
+// TestReceiver implements relog.Receiver, relog.FieldReceiver and relog.EntryReceiver.
+type TestReceiver struct {
+	t         testing.TB
+	verbosity atomic.Int32
+	done      atomic.Bool
+}
+
+// NewTestReceiver creates a new TestReceiver which writes entries of every severity to t's log.
+func NewTestReceiver(t testing.TB) *TestReceiver {
+	r := &TestReceiver{t: t}
+	r.verbosity.Store(relog.LDebug)
+	t.Cleanup(func() { r.done.Store(true) })
+	return r
+}
+
+// log writes an entry for msg and fields, logged from calldepth frames above log, to the test's log.
+func (r *TestReceiver) log(severity int, calldepth int, msg string, fields []relog.Field) {
+	e := relog.Entry{Time: time.Now(), Severity: severity, Message: msg, Fields: fields}
+	if _, file, line, ok := runtime.Caller(calldepth); ok {
+		e.File, e.Line = file, line
+	}
+	r.LogEntry(&e)
+}
+
+// SetOutput is a null function for interface compatibility.
+func (r *TestReceiver) SetOutput(w io.Writer) {}
+
+// SetFlags is a null function for interface compatibility.
+func (r *TestReceiver) SetFlags(flag int, maskOp int) {}
+
+// SetPrefix is a null function for interface compatibility.
+func (r *TestReceiver) SetPrefix(prefix string) {}
+
+// SetVerbosity sets the TestReceiver's verbosity. Messages of lower priority than the verbosity are not logged.
+func (r *TestReceiver) SetVerbosity(verbosity int) { r.verbosity.Store(int32(verbosity)) }
+
+// Output logs s as the message of an entry at severity Notice.
+func (r *TestReceiver) Output(calldepth int, s string) error {
+	r.log(relog.LNotice, calldepth+1, s, nil)
+	return nil
+}
+
+// Log logs the message to the test's log.
+func (r *TestReceiver) Log(severity int, calldepth int, v ...interface{}) {
+	r.log(severity, calldepth+1, fmt.Sprint(v...), nil)
+}
+
+// Logf logs the message to the test's log.
+func (r *TestReceiver) Logf(severity int, calldepth int, format string, v ...interface{}) {
+	r.log(severity, calldepth+1, fmt.Sprintf(format, v...), nil)
+}
+
+// Logln logs the message to the test's log.
+func (r *TestReceiver) Logln(severity int, calldepth int, v ...interface{}) {
+	r.log(severity, calldepth+1, fmt.Sprintln(v...), nil)
+}
+
+// Logw logs msg and fields to the test's log.
+func (r *TestReceiver) Logw(severity int, calldepth int, msg string, fields []relog.Field) {
+	r.log(severity, calldepth+1, msg, fields)
+}
+
+// LogEntry logs e to the test's log.
+func (r *TestReceiver) LogEntry(e *relog.Entry) error {
+	if int(r.verbosity.Load()) < e.Severity || r.done.Load() {
+		return nil
+	}
+	var b bytes.Buffer
+	format(&b, *e)
+	r.t.Log(strings.TrimSuffix(b.String(), "\n"))
+	return nil
+}
@@ -0,0 +1,76 @@
+package relogtest
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/gpitfield/relog"
+)
+
+// fakeT records the failures reported to it.
+type fakeT struct {
+	testing.TB
+	failures []string
+	logs     []string
+	fatal    bool
+}
+
+func (t *fakeT) Helper() {}
+func (t *fakeT) Errorf(format string, v ...interface{}) {
+	t.failures = append(t.failures, fmt.Sprintf(format, v...))
+}
+func (t *fakeT) Fatalf(format string, v ...interface{}) {
+	t.Errorf(format, v...)
+	t.fatal = true
+}
+func (t *fakeT) Log(v ...interface{}) { t.logs = append(t.logs, fmt.Sprint(v...)) }
+func (t *fakeT) Cleanup(f func())     {}
+
+func TestRecorder(t *testing.T) {
+	rec := NewRecorder()
+	relay := relog.New(relog.LDebug, "", 0)
+	relay.AddReceiver(rec)
+
+	relay.Errorf("connection %s", "refused")
+	relay.Errorw("retrying", "attempt", 2)
+	relay.Debugln("detail")
+
+	entries := rec.Entries()
+	if len(entries) != 3 || !strings.HasSuffix(entries[0].File, "relogtest_test.go") || entries[1].Fields[0].Value != 2 || entries[2].Message != "detail" {
+		t.Errorf("Recorded entries didn't match: %+v", entries)
+	}
+
+	ft := &fakeT{}
+	rec.AssertLogged(ft, relog.LError, "refused")
+	rec.AssertNotLogged(ft, relog.LError, "timeout")
+	rec.RequireCount(ft, relog.LError, "", 2)
+	if len(ft.failures) != 0 {
+		t.Errorf("Passing assertions failed: %v", ft.failures)
+	}
+	rec.AssertLogged(ft, relog.LWarn, "refused")
+	rec.AssertNotLogged(ft, relog.LDebug, "detail")
+	rec.RequireCount(ft, relog.LError, "retrying", 2)
+	if len(ft.failures) != 3 || !ft.fatal {
+		t.Fatalf("Failing assertions didn't fail: %v", ft.failures)
+	}
+	exp := regexp.MustCompile(`^relogtest: 1 entries at severity ERROR containing "retrying", want 2\n` +
+		`relogtest_test\.go:\d+: \[ERROR\] connection refused\nrelogtest_test\.go:\d+: \[ERROR\] retrying attempt=2\n` +
+		`relogtest_test\.go:\d+: \[DEBUG\] detail\n$`)
+	if result := ft.failures[2]; !exp.MatchString(result) {
+		t.Errorf("RequireCount failure didn't match\nEXP: %s^\nGOT: %s^", exp, result)
+	}
+}
+
+func TestTestReceiver(t *testing.T) {
+	ft := &fakeT{}
+	relay := relog.New(relog.LDebug, "", 0)
+	relay.AddReceiver(NewTestReceiver(ft))
+	relay.Infow("started", "port", 80)
+
+	exp := regexp.MustCompile(`^relogtest_test\.go:\d+: \[INFO\] started port=80$`)
+	if len(ft.logs) != 1 || !exp.MatchString(ft.logs[0]) {
+		t.Errorf("TestReceiver output didn't match\nEXP: %s^\nGOT: %s^", exp, ft.logs)
+	}
+}
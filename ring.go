@@ -0,0 +1,231 @@
+package relog
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// RingReceiver keeps the most recent log messages in memory, so that debug context around a failure can be
+// dumped without writing every debug message. It holds at most a maximum number of entries and a maximum
+// number of message bytes, discarding the oldest entries to make room, and records messages down to LDebug
+// unless its verbosity is lowered; the Relay forwarding to it must be verbose enough to pass them on.
+// Entries can be taken with Snapshot, written with WriteTo or served over HTTP, and are written to the dump
+// writer, if one is set, when an Emerg message such as from Fatal or Panic is received.
+// RingReceiver implements the Receiver interface, and is safe for concurrent use.
+type RingReceiver struct {
+	verbosity atomic.Int32
+	flag      atomic.Int32
+	prefix    atomic.Value // string
+
+	mu         sync.Mutex
+	entries    []Entry // oldest first
+	size       int     // bytes held by entries
+	maxEntries int
+	maxBytes   int
+	formatter  Formatter
+	dump       io.Writer
+}
+
+// NewRingReceiver creates a new RingReceiver which keeps at most maxEntries entries, if maxEntries is greater
+// than zero, holding at most maxBytes bytes of messages and fields, if maxBytes is greater than zero.
+// Entries are written with TextFormatter using the flags LstdFlags|Lmicroseconds|Lshortfile unless changed.
+func NewRingReceiver(maxEntries int, maxBytes int) *RingReceiver {
+	r := &RingReceiver{maxEntries: maxEntries, maxBytes: maxBytes, formatter: TextFormatter{}}
+	r.verbosity.Store(LDebug)
+	r.flag.Store(LstdFlags | Lmicroseconds | Lshortfile)
+	r.prefix.Store("")
+	return r
+}
+
+// entrySize returns the number of bytes e counts against a RingReceiver's maximum.
+func entrySize(e *Entry) int {
+	n := len(e.Message)
+	for _, f := range e.Fields {
+		n += len(f.Key) + len(fmt.Sprint(f.Value))
+	}
+	return n
+}
+
+// add records e, discarding the oldest entries to keep within the RingReceiver's limits,
+// and dumps the entries if e is an Emerg entry and a dump writer is set.
+func (r *RingReceiver) add(e Entry) {
+	r.mu.Lock()
+	r.entries = append(r.entries, e)
+	r.size += entrySize(&e)
+	for len(r.entries) > 1 && ((r.maxEntries > 0 && len(r.entries) > r.maxEntries) || (r.maxBytes > 0 && r.size > r.maxBytes)) {
+		r.size -= entrySize(&r.entries[0])
+		r.entries[0] = Entry{}
+		r.entries = r.entries[1:]
+	}
+	dump := r.dump
+	r.mu.Unlock()
+	if dump != nil && e.Severity == LEmerg {
+		r.WriteTo(dump)
+	}
+}
+
+// log resolves the caller for msg and fields and records the resulting Entry.
+func (r *RingReceiver) log(severity int, calldepth int, msg string, fields []Field) {
+	if int(r.verbosity.Load()) < severity {
+		return
+	}
+	e := Entry{Time: time.Now(), Severity: severity, Message: msg, Fields: fields}
+	e.File, e.Line = caller(calldepth, Llongfile)
+	r.add(e)
+}
+
+// Snapshot returns copies of the entries held, oldest first, which are at severity or more severe
+// and were logged at or after since. A zero since selects entries regardless of time.
+func (r *RingReceiver) Snapshot(severity int, since time.Time) []Entry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	entries := make([]Entry, 0, len(r.entries))
+	for _, e := range r.entries {
+		if e.Severity <= severity && !e.Time.Before(since) {
+			entries = append(entries, e)
+		}
+	}
+	return entries
+}
+
+// Reset discards the entries held.
+func (r *RingReceiver) Reset() {
+	r.mu.Lock()
+	r.entries, r.size = nil, 0
+	r.mu.Unlock()
+}
+
+// WriteTo writes the entries held to w, oldest first, laid out by the RingReceiver's Formatter.
+func (r *RingReceiver) WriteTo(w io.Writer) (int64, error) {
+	return r.write(w, LDebug, time.Time{})
+}
+
+// write writes the entries selected as by Snapshot to w.
+func (r *RingReceiver) write(w io.Writer, severity int, since time.Time) (int64, error) {
+	entries := r.Snapshot(severity, since)
+	r.mu.Lock()
+	formatter := r.formatter
+	r.mu.Unlock()
+	prefix, flag := r.prefix.Load().(string), int(r.flag.Load())
+	var b bytes.Buffer
+	for i := range entries {
+		e := &entries[i]
+		e.Prefix = prefix
+		e.setFlag(flag)
+		if err := formatter.Format(&b, e); err != nil {
+			return 0, err
+		}
+	}
+	return b.WriteTo(w)
+}
+
+// ServeHTTP writes the entries held. The optional query parameters severity, a severity name or number,
+// and since, an RFC 3339 time or a duration before the present such as "5m", select the entries written.
+func (r *RingReceiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	severity := Severity(LDebug)
+	if s := req.FormValue("severity"); s != "" {
+		var err error
+		if severity, err = ParseSeverity(s); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	var since time.Time
+	if s := req.FormValue("since"); s != "" {
+		if d, err := time.ParseDuration(s); err == nil {
+			since = time.Now().Add(-d)
+		} else if since, err = time.Parse(time.RFC3339, s); err != nil {
+			http.Error(w, "relog: invalid since "+strconv.Quote(s), http.StatusBadRequest)
+			return
+		}
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	r.write(w, int(severity), since)
+}
+
+// SetDumpWriter sets the io.Writer the entries held are written to when an Emerg message is received,
+// as when a Relay's Fatal or Panic is called. A nil writer disables dumping.
+func (r *RingReceiver) SetDumpWriter(w io.Writer) {
+	r.mu.Lock()
+	r.dump = w
+	r.mu.Unlock()
+}
+
+// SetFormatter sets the Formatter used to lay out the RingReceiver's entries when they are written.
+func (r *RingReceiver) SetFormatter(f Formatter) {
+	r.mu.Lock()
+	r.formatter = f
+	r.mu.Unlock()
+}
+
+// SetOutput is a null function for interface compatibility; use SetDumpWriter or WriteTo instead.
+func (r *RingReceiver) SetOutput(w io.Writer) {}
+
+// SetFlags sets the flags used to lay out entries when they are written, via a masking operation.
+func (r *RingReceiver) SetFlags(flag int, maskOp int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	f := int(r.flag.Load())
+	switch maskOp {
+	case NONE:
+		f = flag
+	case AND:
+		f = f & flag
+	case OR:
+		f = f | flag
+	case XOR:
+		f = f ^ flag
+	case ANDNOT:
+		f = f &^ flag
+	}
+	r.flag.Store(int32(f))
+}
+
+// SetPrefix sets the prefix written before each entry.
+func (r *RingReceiver) SetPrefix(prefix string) { r.prefix.Store(prefix) }
+
+// SetVerbosity sets the RingReceiver's verbosity. Messages of lower priority than the verbosity are not recorded.
+func (r *RingReceiver) SetVerbosity(verbosity int) { r.verbosity.Store(int32(verbosity)) }
+
+// Verbosity returns the RingReceiver's verbosity.
+func (r *RingReceiver) Verbosity() int { return int(r.verbosity.Load()) }
+
+// Output records s as the message of an entry at severity Notice.
+func (r *RingReceiver) Output(calldepth int, s string) error {
+	r.log(LNotice, calldepth+1, s, nil)
+	return nil
+}
+
+// Log generates the message and records it.
+func (r *RingReceiver) Log(severity int, calldepth int, v ...interface{}) {
+	r.log(severity, calldepth+1, fmt.Sprint(v...), nil)
+}
+
+// Logf generates the message and records it.
+func (r *RingReceiver) Logf(severity int, calldepth int, format string, v ...interface{}) {
+	r.log(severity, calldepth+1, fmt.Sprintf(format, v...), nil)
+}
+
+// Logln generates the message and records it.
+func (r *RingReceiver) Logln(severity int, calldepth int, v ...interface{}) {
+	r.log(severity, calldepth+1, fmt.Sprintln(v...), nil)
+}
+
+// Logw records msg and fields.
+func (r *RingReceiver) Logw(severity int, calldepth int, msg string, fields []Field) {
+	r.log(severity, calldepth+1, msg, fields)
+}
+
+// LogEntry records e.
+func (r *RingReceiver) LogEntry(e *Entry) error {
+	if int(r.verbosity.Load()) >= e.Severity {
+		r.add(*e)
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package relog
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+	"time"
+)
+
+func TestRingReceiver(t *testing.T) {
+	ring := NewRingReceiver(3, 0)
+	ring.SetFlags(Lshortfile, NONE)
+	relay := New(LDebug, "", 0)
+	relay.AddReceiver(ring)
+
+	relay.Debugf("one")
+	relay.Infof("two")
+	relay.Warnw("three", "k", "v")
+	relay.Errorln("four")
+
+	var output bytes.Buffer
+	ring.WriteTo(&output)
+	exp := regexp.MustCompile(`^ring_test\.go:\d+: \[INFO\] two\nring_test\.go:\d+: \[WARNING\] three k=v\nring_test\.go:\d+: \[ERROR\] four\n$`)
+	if result := output.String(); !exp.MatchString(result) {
+		t.Errorf("RingReceiver output didn't match\nEXP: %s^\nGOT: %s^", exp, result)
+	}
+
+	if entries := ring.Snapshot(LWarn, time.Time{}); len(entries) != 2 || entries[0].Message != "three" {
+		t.Errorf("Snapshot by severity didn't match\nEXP: %s^\nGOT: %v^", "[three four]", entries)
+	}
+	if entries := ring.Snapshot(LDebug, time.Now().Add(time.Minute)); len(entries) != 0 {
+		t.Errorf("Snapshot by time didn't match\nEXP: %s^\nGOT: %v^", "[]", entries)
+	}
+
+	rec := httptest.NewRecorder()
+	ring.ServeHTTP(rec, httptest.NewRequest("GET", "/?severity=error&since=1m", nil))
+	exp = regexp.MustCompile(`^ring_test\.go:\d+: \[ERROR\] four\n$`)
+	if result := rec.Body.String(); !exp.MatchString(result) {
+		t.Errorf("RingReceiver HTTP output didn't match\nEXP: %s^\nGOT: %s^", exp, result)
+	}
+}
+
+func TestRingReceiverBytes(t *testing.T) {
+	ring := NewRingReceiver(0, 10)
+	ring.SetFlags(0, NONE)
+	var dump bytes.Buffer
+	ring.SetDumpWriter(&dump)
+	relay := New(LDebug, "", 0)
+	relay.AddReceiver(ring)
+
+	relay.Debugf("12345")
+	relay.Debugf("67890")
+	relay.Debugf("abcde")
+	if dump.Len() != 0 {
+		t.Errorf("RingReceiver dumped before an Emerg message: %s", dump.String())
+	}
+	relay.Emergf("fail")
+
+	exp := "[DEBUG] abcde\n[EMERGENCY] fail\n"
+	if result := dump.String(); result != exp {
+		t.Errorf("RingReceiver dump didn't match\nEXP: %s^\nGOT: %s^", exp, result)
+	}
+}
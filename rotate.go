@@ -0,0 +1,335 @@
+package relog
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RotatingFile rotation periods.
+const (
+	RotateNever = iota
+	RotateHourly
+	RotateDaily
+)
+
+// backupTimeFormat is the layout of the timestamp added to the names of rotated files.
+const backupTimeFormat = "2006-01-02T15-04-05.000"
+
+// rotateRetryDelay is the time after a failed rotation before the file is rotated for size again,
+// so that every write doesn't retry the rotation while it keeps failing.
+const rotateRetryDelay = time.Minute
+
+// renameFile renames the current file to its backup name when rotating; tests replace it to simulate failures.
+var renameFile = os.Rename
+
+// RotatingFile is an io.WriteCloser which writes to a file, moving it aside and starting a new one when it would
+// exceed a maximum size, when a new hour or day begins, or on demand. Rotated files are renamed to include the time
+// of rotation, e.g. app.log becomes app-2009-01-23T01-23-23.000.log, and are optionally gzip compressed.
+// Each Write goes entirely to one file, so as a Collector writes one entry per Write, entries are never split
+// across files. RotatingFile is safe for concurrent use, and is typically passed to NewCollector or Relay.AddWriter.
+type RotatingFile struct {
+	mu         sync.Mutex
+	path       string
+	maxSize    int64
+	every      int
+	maxBackups int
+	maxAge     time.Duration
+	compress   bool
+	symlink    string
+	file       *os.File
+	size       int64
+	next       time.Time // when the file is next due to be rotated by time
+	retry      time.Time // after a failed rotation, when the file may next be rotated for size
+	now        func() time.Time
+	cleanup    sync.WaitGroup // compression and removal of backups in progress
+	cleanMu    sync.Mutex     // serializes compression and removal of backups
+}
+
+// OpenRotatingFile opens or creates the file at path for appending, and returns a RotatingFile which rotates it
+// when it would exceed maxSize bytes (if maxSize is greater than zero) or at the start of every period
+// (RotateNever, RotateHourly or RotateDaily), keeping at most maxBackups rotated files (if maxBackups is greater than zero).
+func OpenRotatingFile(path string, maxSize int64, every int, maxBackups int) (*RotatingFile, error) {
+	f := &RotatingFile{
+		path:       path,
+		maxSize:    maxSize,
+		every:      every,
+		maxBackups: maxBackups,
+		now:        time.Now,
+	}
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// SetMaxAge sets the age beyond which rotated files are removed. Zero keeps files regardless of age.
+func (f *RotatingFile) SetMaxAge(maxAge time.Duration) {
+	f.mu.Lock()
+	f.maxAge = maxAge
+	f.mu.Unlock()
+}
+
+// SetCompress sets whether rotated files are gzip compressed.
+func (f *RotatingFile) SetCompress(compress bool) {
+	f.mu.Lock()
+	f.compress = compress
+	f.mu.Unlock()
+}
+
+// SetSymlink sets the path of a symbolic link which is kept pointing to the file being written,
+// e.g. to expose it as "current" in another directory. An empty link removes the existing one, if any.
+func (f *RotatingFile) SetSymlink(link string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if link == "" && f.symlink != "" {
+		os.Remove(f.symlink)
+	}
+	f.symlink = link
+	return f.link()
+}
+
+// link points the RotatingFile's symlink, if any, to its file. The caller must hold f.mu.
+func (f *RotatingFile) link() error {
+	if f.symlink == "" {
+		return nil
+	}
+	target, err := filepath.Abs(f.path)
+	if err != nil {
+		return err
+	}
+	tmp := f.symlink + ".tmp"
+	os.Remove(tmp)
+	if err := os.Symlink(target, tmp); err != nil {
+		return err
+	}
+	return os.Rename(tmp, f.symlink)
+}
+
+// open opens the file for appending and computes when it is next due to be rotated. The caller must hold f.mu.
+func (f *RotatingFile) open() error {
+	if err := os.MkdirAll(filepath.Dir(f.path), 0755); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(f.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	f.file = file
+	f.size = info.Size()
+	f.schedule(f.now())
+	return f.link()
+}
+
+// schedule computes when the file is next due to be rotated by time, after now. The caller must hold f.mu.
+func (f *RotatingFile) schedule(now time.Time) {
+	switch f.every {
+	case RotateHourly:
+		f.next = time.Date(now.Year(), now.Month(), now.Day(), now.Hour()+1, 0, 0, 0, now.Location())
+	case RotateDaily:
+		f.next = time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, now.Location())
+	default:
+		f.next = time.Time{}
+	}
+}
+
+// Write writes p to the file, first rotating it if p would take it past its maximum size, or if it is due
+// for rotation by time. An empty file is never rotated for size, so writes larger than the maximum size still succeed.
+// If the rotation fails, p is written to the current file all the same, and the rotation's error is returned only
+// if that write fails too. Further writes go to the current file, without retrying the rotation, until the next
+// period begins, or for size, until a minute has passed.
+func (f *RotatingFile) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.file == nil {
+		return 0, os.ErrClosed
+	}
+	now := f.now()
+	full := f.maxSize > 0 && f.size > 0 && f.size+int64(len(p)) > f.maxSize && !now.Before(f.retry)
+	if full || (!f.next.IsZero() && !now.Before(f.next)) {
+		if err := f.rotate(); err != nil {
+			if f.file == nil {
+				return 0, err
+			}
+			n, e := f.file.Write(p) // the file was reopened, so p is written to it rather than lost
+			f.size += int64(n)
+			if e != nil {
+				return n, err
+			}
+			return n, nil
+		}
+	}
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	return n, err
+}
+
+// Rotate rotates the file immediately.
+func (f *RotatingFile) Rotate() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.file == nil {
+		return os.ErrClosed
+	}
+	return f.rotate()
+}
+
+// rotate closes the file, renames it with a timestamp, and opens a new one, compressing and removing
+// old backups in the background. The caller must hold f.mu.
+func (f *RotatingFile) rotate() error {
+	err := f.file.Close()
+	f.file = nil
+	if err != nil {
+		return f.reopen(err)
+	}
+	ext := filepath.Ext(f.path)
+	var backup string
+	for t := f.now(); ; t = t.Add(time.Millisecond) { // never overwrite a backup rotated in the same millisecond
+		backup = strings.TrimSuffix(f.path, ext) + "-" + t.Format(backupTimeFormat) + ext
+		if _, err := os.Lstat(backup); os.IsNotExist(err) {
+			if _, err := os.Lstat(backup + ".gz"); os.IsNotExist(err) {
+				break
+			}
+		}
+	}
+	if err := renameFile(f.path, backup); err != nil && !os.IsNotExist(err) {
+		return f.reopen(err)
+	}
+	if err := f.open(); err != nil {
+		return f.reopen(err)
+	}
+	f.cleanup.Add(1)
+	go f.clean(backup, f.compress, f.maxBackups, f.maxAge)
+	return nil
+}
+
+// reopen reopens the file at f.path for appending after a failed rotation, unless it is still open,
+// so that later writes are not lost, and returns err. The rotation is not retried until the next period begins,
+// or for size, until rotateRetryDelay has passed. The caller must hold f.mu.
+func (f *RotatingFile) reopen(err error) error {
+	now := f.now()
+	f.schedule(now)
+	f.retry = now.Add(rotateRetryDelay)
+	if f.file != nil {
+		return err
+	}
+	file, e := os.OpenFile(f.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if e != nil {
+		return err
+	}
+	f.file = file
+	if info, e := file.Stat(); e == nil {
+		f.size = info.Size()
+	}
+	return err
+}
+
+// clean compresses the newly rotated backup if required, then removes backups beyond maxBackups or older than maxAge.
+func (f *RotatingFile) clean(backup string, compress bool, maxBackups int, maxAge time.Duration) {
+	defer f.cleanup.Done()
+	f.cleanMu.Lock()
+	defer f.cleanMu.Unlock()
+	if compress {
+		if err := gzipFile(backup); err == nil {
+			os.Remove(backup)
+		}
+	}
+	backups := f.backups()
+	cutoff := f.now().Add(-maxAge)
+	for i, b := range backups {
+		if (maxBackups > 0 && i >= maxBackups) || (maxAge > 0 && b.t.Before(cutoff)) {
+			os.Remove(b.path)
+		}
+	}
+}
+
+type backupFile struct {
+	path string
+	t    time.Time
+}
+
+// backups returns the RotatingFile's rotated files, newest first.
+func (f *RotatingFile) backups() []backupFile {
+	ext := filepath.Ext(f.path)
+	prefix := filepath.Base(strings.TrimSuffix(f.path, ext)) + "-"
+	entries, err := os.ReadDir(filepath.Dir(f.path))
+	if err != nil {
+		return nil
+	}
+	var backups []backupFile
+	for _, e := range entries {
+		name := e.Name()
+		stamp := strings.TrimSuffix(name, ".gz")
+		if !strings.HasPrefix(stamp, prefix) || !strings.HasSuffix(stamp, ext) {
+			continue
+		}
+		stamp = strings.TrimSuffix(strings.TrimPrefix(stamp, prefix), ext)
+		t, err := time.ParseInLocation(backupTimeFormat, stamp, time.Local)
+		if err != nil {
+			continue
+		}
+		backups = append(backups, backupFile{filepath.Join(filepath.Dir(f.path), name), t})
+	}
+	sort.Slice(backups, func(i, j int) bool { return backups[i].t.After(backups[j].t) })
+	return backups
+}
+
+// gzipFile writes a gzip compressed copy of the file at path to path.gz.
+func gzipFile(path string) error {
+	in, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	tmp := path + ".gz.tmp"
+	out, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	zw := gzip.NewWriter(out)
+	_, err = io.Copy(zw, in)
+	if e := zw.Close(); err == nil {
+		err = e
+	}
+	if e := out.Close(); err == nil {
+		err = e
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, path+".gz")
+}
+
+// Sync commits the file's contents to stable storage.
+func (f *RotatingFile) Sync() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.file == nil {
+		return os.ErrClosed
+	}
+	return f.file.Sync()
+}
+
+// Close closes the file, and waits for any compression or removal of backups to finish.
+func (f *RotatingFile) Close() error {
+	f.mu.Lock()
+	var err error
+	if f.file != nil {
+		err = f.file.Close()
+		f.file = nil
+	}
+	f.mu.Unlock()
+	f.cleanup.Wait()
+	return err
+}
@@ -0,0 +1,118 @@
+package relog
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRotatingFileSize(t *testing.T) {
+	dir := t.TempDir()
+	f, err := OpenRotatingFile(filepath.Join(dir, "app.log"), 64, RotateNever, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.SetCompress(true)
+	if err := f.SetSymlink(filepath.Join(dir, "current")); err != nil {
+		t.Fatal(err)
+	}
+	relay := New(LDebug, "", 0)
+	relay.AddWriter(f, LDebug, "", 0)
+	for i := 0; i < 20; i++ {
+		relay.Infof("rotate %02d", i) // 17 bytes, so three entries per file
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	backups := f.backups()
+	if len(backups) != 2 {
+		t.Fatalf("RotatingFile kept wrong number of backups EXP: 2 GOT: %d", len(backups))
+	}
+	var contents []string
+	for i := len(backups) - 1; i >= 0; i-- {
+		if !strings.HasSuffix(backups[i].path, ".log.gz") {
+			t.Fatalf("RotatingFile backup not compressed: %s", backups[i].path)
+		}
+		in, err := os.Open(backups[i].path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		zr, err := gzip.NewReader(in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, _ := io.ReadAll(zr)
+		in.Close()
+		contents = append(contents, string(b))
+	}
+	b, _ := os.ReadFile(filepath.Join(dir, "current"))
+	contents = append(contents, string(b))
+	exp := "[INFO] rotate 12\n[INFO] rotate 13\n[INFO] rotate 14\n[INFO] rotate 15\n[INFO] rotate 16\n[INFO] rotate 17\n[INFO] rotate 18\n[INFO] rotate 19\n"
+	if result := strings.Join(contents, ""); result != exp {
+		t.Errorf("RotatingFile contents didn't match\nEXP: %s^\nGOT: %s^", exp, result)
+	}
+}
+
+func TestRotatingFileTime(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Date(2009, 1, 23, 1, 59, 59, 0, time.Local)
+	f, err := OpenRotatingFile(filepath.Join(dir, "app.log"), 0, RotateHourly, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.now = func() time.Time { return now }
+	f.file.Close()
+	f.open() // recompute the rotation time using the fake clock
+	f.Write([]byte("before\n"))
+	now = now.Add(time.Second)
+	f.Write([]byte("after\n"))
+	f.Close()
+
+	b, _ := os.ReadFile(filepath.Join(dir, "app-2009-01-23T02-00-00.000.log"))
+	if string(b) != "before\n" {
+		t.Errorf("RotatingFile backup contents didn't match\nEXP: before\n^\nGOT: %s^", b)
+	}
+	b, _ = os.ReadFile(filepath.Join(dir, "app.log"))
+	if string(b) != "after\n" {
+		t.Errorf("RotatingFile contents didn't match\nEXP: after\n^\nGOT: %s^", b)
+	}
+}
+
+func TestRotatingFileRenameError(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	f, err := OpenRotatingFile(path, 8, RotateNever, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	now := time.Date(2009, 1, 23, 1, 23, 23, 0, time.Local)
+	f.now = func() time.Time { return now }
+	f.Write([]byte("before\n"))
+	defer func() { renameFile = os.Rename }()
+	renames := 0
+	renameFile = func(string, string) error { renames++; return os.ErrPermission }
+	if err := f.Rotate(); err != os.ErrPermission {
+		t.Errorf("Rotate error didn't match\nEXP: %v^\nGOT: %v^", os.ErrPermission, err)
+	}
+	if _, err := f.Write([]byte("after\n")); err != nil {
+		t.Errorf("Write after a failed rotation failed: %v", err)
+	}
+	if b, _ := os.ReadFile(path); string(b) != "before\nafter\n" || renames != 1 {
+		t.Errorf("RotatingFile contents didn't match\nEXP: before\nafter\n^\nGOT: %s^ after %d renames", b, renames)
+	}
+	now = now.Add(rotateRetryDelay)
+	if _, err := f.Write([]byte("retry\n")); err != nil {
+		t.Errorf("Write triggering a failed rotation failed: %v", err)
+	}
+	if renames != 2 {
+		t.Errorf("RotatingFile didn't retry the rotation EXP: 2 renames GOT: %d", renames)
+	}
+	if b, _ := os.ReadFile(path); string(b) != "before\nafter\nretry\n" {
+		t.Errorf("RotatingFile contents didn't match\nEXP: before\nafter\nretry\n^\nGOT: %s^", b)
+	}
+}
@@ -0,0 +1,300 @@
+package relog
+
+import (
+	"fmt"
+	"io"
+	"runtime"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// maxSamplingKeys is the most keys a SamplingReceiver counts; when a new key would exceed it, the counts are
+// cleared as at the end of an interval, so that unique messages can't grow the counts without bound.
+const maxSamplingKeys = 10000
+
+// SamplingReceiver sampling keys. With SampleByMessage, entries logged via Logf are keyed by their format string,
+// and all others by their formatted message.
+const (
+	SampleByMessage = iota // entries with the same message, or format string for Logf, are sampled together
+	SampleByCaller         // entries logged from the same call site are sampled together
+)
+
+// tokenBucket limits a rate of events, allowing bursts of up to burst events.
+type tokenBucket struct {
+	rate   float64 // tokens added per second
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// allow takes a token from the bucket if one is available.
+func (b *tokenBucket) allow(now time.Time) bool {
+	b.tokens += now.Sub(b.last).Seconds() * b.rate
+	if b.tokens > b.burst {
+		b.tokens = b.burst
+	}
+	b.last = now
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// SamplingReceiver limits the entries passed to its Receiver, using per severity token bucket rate limits
+// and "first N, then every Mth" sampling of entries with the same key in each interval. Entries at exempt
+// severities are always passed on. If a summary interval is set, the number of entries suppressed in each
+// interval is reported to the Receiver at severity Warn.
+// SamplingReceiver implements the Receiver interface.
+type SamplingReceiver struct {
+	rcvr Receiver
+	now  func() time.Time
+
+	mu         sync.Mutex
+	buckets    map[int]*tokenBucket
+	exempt     map[int]bool
+	first      int
+	thereafter int
+	interval   time.Duration
+	keyBy      int
+	counts     map[string]int
+	reset      time.Time // when counts are next cleared
+	suppressed map[int]uint64
+	stop       chan struct{} // closed to stop the summary goroutine
+}
+
+// NewSamplingReceiver creates a new SamplingReceiver which passes entries to rcvr.
+// Until limits are set, all entries are passed on.
+func NewSamplingReceiver(rcvr Receiver) *SamplingReceiver {
+	return &SamplingReceiver{
+		rcvr:       rcvr,
+		now:        time.Now,
+		buckets:    make(map[int]*tokenBucket),
+		exempt:     make(map[int]bool),
+		counts:     make(map[string]int),
+		suppressed: make(map[int]uint64),
+	}
+}
+
+// SetRate limits entries at severity to perSecond on average, in bursts of up to burst entries.
+// A perSecond of zero or less removes the limit.
+func (s *SamplingReceiver) SetRate(severity int, perSecond float64, burst int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if perSecond <= 0 {
+		delete(s.buckets, severity)
+		return
+	}
+	s.buckets[severity] = &tokenBucket{rate: perSecond, burst: float64(burst), tokens: float64(burst), last: s.now()}
+}
+
+// SetSampling passes on the first entries with each key in every interval, then every thereafter'th entry,
+// keying entries by message or by caller according to keyBy (SampleByMessage or SampleByCaller).
+// A first of zero or less disables sampling, and a thereafter of zero or less suppresses all entries after the first.
+// With SampleByMessage, messages logged via Logf are keyed by their format string, including those a Relay
+// formats before passing them on, as it does for bound fields, but messages logged via Log, Logln and Logw
+// are keyed by the formatted message, so messages varying in their values are only sampled together via Logf.
+// An interval of zero or less never clears the counts, except that, whatever the interval, they are cleared
+// when 10000 keys have been counted, to bound their memory use.
+func (s *SamplingReceiver) SetSampling(first, thereafter int, interval time.Duration, keyBy int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.first, s.thereafter, s.interval, s.keyBy = first, thereafter, interval, keyBy
+	s.counts = make(map[string]int)
+	s.reset = s.now().Add(interval)
+}
+
+// SetExempt sets the severities which are never rate limited or sampled, e.g. LEmerg and LAlert.
+func (s *SamplingReceiver) SetExempt(severities ...int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.exempt = make(map[int]bool)
+	for _, severity := range severities {
+		s.exempt[severity] = true
+	}
+}
+
+// SetSummaryInterval starts reporting the number of suppressed entries to the Receiver every interval,
+// if any were suppressed. An interval of zero or less stops reporting.
+func (s *SamplingReceiver) SetSummaryInterval(interval time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stop != nil {
+		close(s.stop)
+		s.stop = nil
+	}
+	if interval <= 0 {
+		return
+	}
+	s.stop = make(chan struct{})
+	go func(stop chan struct{}) {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				s.Summarize()
+			case <-stop:
+				return
+			}
+		}
+	}(s.stop)
+}
+
+// Summarize reports the number of entries suppressed since the last summary to the Receiver,
+// at severity Warn, if any were suppressed.
+func (s *SamplingReceiver) Summarize() {
+	s.mu.Lock()
+	var total uint64
+	fields := make([]Field, 0, len(s.suppressed))
+	ranks := make([]int, 0, len(s.suppressed))
+	for severity := range s.suppressed {
+		ranks = append(ranks, severity)
+	}
+	sort.Ints(ranks)
+	for _, severity := range ranks {
+		if n := s.suppressed[severity]; n > 0 {
+			total += n
+			fields = append(fields, Field{severityLabel(severity), n})
+		}
+	}
+	s.suppressed = make(map[int]uint64)
+	s.mu.Unlock()
+	if total > 0 {
+		logEntry(s.rcvr, &Entry{Time: s.now(), Severity: LWarn, Message: fmt.Sprintf("suppressed %d log entries", total), Fields: fields})
+	}
+}
+
+// Flush flushes the Receiver if it is a Flusher.
+func (s *SamplingReceiver) Flush() error { return flush(s.rcvr) }
+
+// Close stops reporting suppressed entries, and closes the Receiver if it is a Closer.
+func (s *SamplingReceiver) Close() error {
+	s.SetSummaryInterval(0)
+	return closeReceiver(s.rcvr)
+}
+
+// key returns the sampling key for an entry with message key msgKey, logged from the call site calldepth frames up.
+func (s *SamplingReceiver) key(calldepth int, msgKey string) string {
+	s.mu.Lock()
+	keyBy := s.keyBy
+	s.mu.Unlock()
+	if keyBy != SampleByCaller {
+		return msgKey
+	}
+	var pcs [1]uintptr
+	runtime.Callers(calldepth+1, pcs[:]) // +1 for runtime.Callers itself
+	return strconv.FormatUint(uint64(pcs[0]), 16)
+}
+
+// allow reports whether an entry at severity with the given key should be passed on, counting it as suppressed if not.
+func (s *SamplingReceiver) allow(severity int, key string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.exempt[severity] {
+		return true
+	}
+	now := s.now()
+	if s.first > 0 {
+		if s.interval > 0 && !now.Before(s.reset) {
+			s.counts = make(map[string]int)
+			s.reset = now.Add(s.interval)
+		}
+		key = strconv.Itoa(severity) + ":" + key
+		if _, ok := s.counts[key]; !ok && len(s.counts) >= maxSamplingKeys {
+			s.counts = make(map[string]int)
+		}
+		s.counts[key]++
+		n := s.counts[key]
+		if n > s.first && (s.thereafter <= 0 || (n-s.first)%s.thereafter != 0) {
+			s.suppressed[severity]++
+			return false
+		}
+	}
+	if b, ok := s.buckets[severity]; ok && !b.allow(now) {
+		s.suppressed[severity]++
+		return false
+	}
+	return true
+}
+
+// SetOutput calls SetOutput on the SamplingReceiver's Receiver.
+func (s *SamplingReceiver) SetOutput(w io.Writer) { s.rcvr.SetOutput(w) }
+
+// SetFlags calls SetFlags on the SamplingReceiver's Receiver.
+func (s *SamplingReceiver) SetFlags(flag int, maskOp int) { s.rcvr.SetFlags(flag, maskOp) }
+
+// SetPrefix calls SetPrefix on the SamplingReceiver's Receiver.
+func (s *SamplingReceiver) SetPrefix(prefix string) { s.rcvr.SetPrefix(prefix) }
+
+// SetVerbosity calls SetVerbosity on the SamplingReceiver's Receiver.
+func (s *SamplingReceiver) SetVerbosity(verbosity int) { s.rcvr.SetVerbosity(verbosity) }
+
+// Output passes s to the Receiver's Output if an entry at severity Notice with message s is allowed.
+func (s *SamplingReceiver) Output(calldepth int, str string) error {
+	if !s.allow(LNotice, s.key(calldepth+1, str)) {
+		return nil
+	}
+	return s.rcvr.Output(calldepth+1, str)
+}
+
+// Log passes the message to the Receiver if allowed.
+func (s *SamplingReceiver) Log(severity int, calldepth int, v ...interface{}) {
+	if s.allow(severity, s.key(calldepth+1, fmt.Sprint(v...))) {
+		s.rcvr.Log(severity, calldepth+1, v...)
+	}
+}
+
+// Logf passes the message to the Receiver if allowed. With SampleByMessage, messages are keyed by their format string.
+func (s *SamplingReceiver) Logf(severity int, calldepth int, format string, v ...interface{}) {
+	if s.allow(severity, s.key(calldepth+1, format)) {
+		s.rcvr.Logf(severity, calldepth+1, format, v...)
+	}
+}
+
+// Logln passes the message to the Receiver if allowed.
+func (s *SamplingReceiver) Logln(severity int, calldepth int, v ...interface{}) {
+	if s.allow(severity, s.key(calldepth+1, fmt.Sprint(v...))) {
+		s.rcvr.Logln(severity, calldepth+1, v...)
+	}
+}
+
+// Logw passes msg and fields to the Receiver if allowed.
+func (s *SamplingReceiver) Logw(severity int, calldepth int, msg string, fields []Field) {
+	s.logwf(severity, calldepth+1, "", msg, fields)
+}
+
+// logwf is Logw for msg formatted from format. With SampleByMessage, messages with a format are keyed by it.
+func (s *SamplingReceiver) logwf(severity int, calldepth int, format string, msg string, fields []Field) {
+	key := msg
+	if format != "" {
+		key = format
+	}
+	if !s.allow(severity, s.key(calldepth+1, key)) {
+		return
+	}
+	if fr, ok := s.rcvr.(FieldReceiver); ok {
+		fr.Logw(severity, calldepth+1, msg, fields)
+	} else {
+		s.rcvr.Log(severity, calldepth+1, msg+formatFields(fields))
+	}
+}
+
+// LogEntry passes e to the Receiver if allowed. With SampleByCaller, entries are keyed by their file and line.
+func (s *SamplingReceiver) LogEntry(e *Entry) error {
+	key := e.Message
+	if e.format != "" {
+		key = e.format
+	}
+	s.mu.Lock()
+	if s.keyBy == SampleByCaller {
+		key = e.File + ":" + strconv.Itoa(e.Line)
+	}
+	s.mu.Unlock()
+	if !s.allow(e.Severity, key) {
+		return nil
+	}
+	return logEntry(s.rcvr, e)
+}
@@ -0,0 +1,115 @@
+package relog
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSamplingReceiver(t *testing.T) {
+	var output bytes.Buffer
+	now := time.Date(2009, 1, 23, 1, 23, 23, 0, time.UTC)
+	sampler := NewSamplingReceiver(NewCollector(&output, LDebug, "", 0))
+	sampler.now = func() time.Time { return now }
+	sampler.SetSampling(2, 3, time.Second, SampleByMessage)
+	sampler.SetExempt(LEmerg)
+	relay := New(LDebug, "", 0)
+	relay.AddReceiver(sampler)
+
+	for i := 0; i < 10; i++ {
+		relay.Errorf("sample %d", i) // keyed by format, so passes 0, 1, 4 and 7
+		relay.Emerg("sample")
+	}
+	now = now.Add(time.Second)
+	relay.Errorf("sample %d", 10)
+	sampler.Summarize()
+
+	exp := "[ERROR] sample 0\n[ERROR] sample 1\n[ERROR] sample 4\n[ERROR] sample 7\n[ERROR] sample 10\n" +
+		"[WARNING] suppressed 6 log entries ERROR=6\n"
+	if result := strings.Replace(output.String(), "[EMERGENCY] sample\n", "", 10); result != exp {
+		t.Errorf("SamplingReceiver messages didn't match\nEXP: %s^\nGOT: %s^", exp, result)
+	}
+}
+
+func TestSamplingReceiverWith(t *testing.T) {
+	var output bytes.Buffer
+	sampler := NewSamplingReceiver(NewCollector(&output, LDebug, "", 0))
+	sampler.SetSampling(1, 0, 0, SampleByMessage)
+	relay := New(LDebug, "", 0)
+	relay.AddReceiver(sampler)
+	derived := relay.With("k", 1)
+	named := relay.Named("app")
+	handled := relay.Named("db") // passes messages to the sampler via LogEntry
+	handled.SetErrorHandler(func(*WriteError) {}, 0)
+
+	for i := 0; i < 2; i++ {
+		relay.Infof("root %d", i)
+		derived.Infof("derived %d", i)
+		named.Infof("named %d", i)
+		handled.Infof("handled %d", i)
+		derived.Infow("fields", "i", i) // keyed by message, as there is no format
+	}
+	exp := "[INFO] root 0\n[INFO] derived 0 k=1\n[INFO] named 0 logger=app\n[INFO] handled 0 logger=db\n[INFO] fields k=1 i=0\n"
+	if result := output.String(); result != exp {
+		t.Errorf("SamplingReceiver messages didn't match\nEXP: %s^\nGOT: %s^", exp, result)
+	}
+}
+
+func TestSamplingReceiverRate(t *testing.T) {
+	var output bytes.Buffer
+	now := time.Date(2009, 1, 23, 1, 23, 23, 0, time.UTC)
+	sampler := NewSamplingReceiver(NewCollector(&output, LDebug, "", 0))
+	sampler.now = func() time.Time { return now }
+	sampler.SetRate(LWarn, 2, 2)
+	relay := New(LDebug, "", 0)
+	relay.AddReceiver(sampler)
+
+	for i := 0; i < 4; i++ {
+		relay.Warn(i)
+		relay.Info(i)
+	}
+	now = now.Add(500 * time.Millisecond)
+	relay.Warn(4)
+	relay.Warn(5)
+
+	exp := "[WARNING] 0\n[INFO] 0\n[WARNING] 1\n[INFO] 1\n[INFO] 2\n[INFO] 3\n[WARNING] 4\n"
+	if result := output.String(); result != exp {
+		t.Errorf("SamplingReceiver messages didn't match\nEXP: %s^\nGOT: %s^", exp, result)
+	}
+}
+
+func TestSamplingReceiverCaller(t *testing.T) {
+	var output bytes.Buffer
+	sampler := NewSamplingReceiver(NewCollector(&output, LDebug, "", 0))
+	sampler.SetSampling(1, 0, time.Minute, SampleByCaller)
+	relay := New(LDebug, "", 0)
+	relay.AddReceiver(sampler)
+
+	for i := 0; i < 3; i++ {
+		relay.Info("first")
+		relay.Info("second")
+	}
+	if exp, result := "[INFO] first\n[INFO] second\n", output.String(); result != exp {
+		t.Errorf("SamplingReceiver messages didn't match\nEXP: %s^\nGOT: %s^", exp, result)
+	}
+}
+
+func TestSamplingReceiverKeyLimit(t *testing.T) {
+	var output bytes.Buffer
+	sampler := NewSamplingReceiver(NewCollector(&output, LDebug, "", 0))
+	sampler.SetSampling(1, 0, 0, SampleByMessage)
+	for i := 0; i < maxSamplingKeys+10; i++ {
+		sampler.Log(LInfo, 1, "unique ", i)
+	}
+	sampler.mu.Lock()
+	n := len(sampler.counts)
+	sampler.mu.Unlock()
+	if n > maxSamplingKeys {
+		t.Errorf("SamplingReceiver counted too many keys\nEXP: %d^\nGOT: %d^", maxSamplingKeys, n)
+	}
+	sampler.Log(LInfo, 1, "unique ", maxSamplingKeys+9)
+	if lines := strings.Count(output.String(), "\n"); lines != maxSamplingKeys+10 {
+		t.Errorf("SamplingReceiver messages didn't match\nEXP: %d^\nGOT: %d^", maxSamplingKeys+10, lines)
+	}
+}
@@ -0,0 +1,86 @@
+package relog
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Severity is the priority of a log message, from LEmerg, the most severe, to LDebug. The severity constants
+// are untyped, so they can be used wherever an int severity or verbosity is expected, as well as a Severity.
+// Severity implements flag.Value, so that a verbosity can be set from the command line:
+//
+//	verbosity := relog.Severity(relog.LInfo)
+//	flag.Var(&verbosity, "v", "log verbosity, e.g. debug or 7")
+//	flag.Parse()
+//	relog.SetVerbosity(int(verbosity))
+type Severity int
+
+// severityNames maps lower case severity names, and the RFC 3164 keywords and other common abbreviations, to severities.
+var severityNames = map[string]Severity{
+	"emergency": LEmerg, "emerg": LEmerg, "panic": LEmerg, "alert": LAlert, "critical": LCritical, "crit": LCritical,
+	"error": LError, "err": LError, "warning": LWarn, "warn": LWarn, "notice": LNotice,
+	"info": LInfo, "informational": LInfo, "debug": LDebug,
+}
+
+// ParseSeverity converts a severity name such as "warning", in any case, an RFC 3164 keyword such as "crit" or "err",
+// the name of a severity defined with DefineSeverity, or the number of a built-in or custom severity to a Severity.
+func ParseSeverity(s string) (Severity, error) {
+	s = strings.TrimSpace(s)
+	name := strings.ToLower(s)
+	if severity, ok := severityNames[name]; ok {
+		return severity, nil
+	}
+	if severity, ok := severityRegistryLoad().byName[name]; ok {
+		return severity, nil
+	}
+	if n, err := strconv.Atoi(s); err == nil && definedSeverity(n) {
+		return Severity(n), nil
+	}
+	return 0, fmt.Errorf("relog: invalid severity %q", s)
+}
+
+// String returns the severity's label, e.g. "WARNING" or the upper case name of a custom severity, or its number if it has none.
+func (s Severity) String() string {
+	if s >= LEmerg && int(s) < len(severities) {
+		return severities[s]
+	}
+	if c, ok := severityRegistryLoad().byRank[int(s)]; ok {
+		return c.label
+	}
+	return strconv.Itoa(int(s))
+}
+
+// Set sets the severity from a name or number, as by ParseSeverity, for flag.Value.
+func (s *Severity) Set(value string) error {
+	severity, err := ParseSeverity(value)
+	if err != nil {
+		return err
+	}
+	*s = severity
+	return nil
+}
+
+// MarshalText returns the severity's label.
+func (s Severity) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// UnmarshalText sets the severity from a name or number, as by ParseSeverity.
+func (s *Severity) UnmarshalText(text []byte) error {
+	return s.Set(string(text))
+}
+
+// UnmarshalJSON sets the severity from a JSON string holding a name or number, or from a JSON number.
+func (s *Severity) UnmarshalJSON(data []byte) error {
+	var n json.Number
+	if err := json.Unmarshal(data, &n); err == nil {
+		return s.Set(n.String())
+	}
+	var name string
+	if err := json.Unmarshal(data, &name); err != nil {
+		return fmt.Errorf("relog: invalid severity %s", data)
+	}
+	return s.Set(name)
+}
@@ -0,0 +1,71 @@
+package relog
+
+import (
+	"encoding/json"
+	"flag"
+	"testing"
+)
+
+var SeverityTests = []struct {
+	in  string
+	exp Severity
+	err bool
+}{
+	{"debug", LDebug, false},
+	{"WARNING", LWarn, false},
+	{"emerg", LEmerg, false},
+	{"crit", LCritical, false},
+	{"err", LError, false},
+	{" Notice ", LNotice, false},
+	{"3", LError, false},
+	{" 3\n", LError, false},
+	{"8", 0, true},
+	{"-1", 0, true},
+	{"loud", 0, true},
+}
+
+func TestParseSeverity(t *testing.T) {
+	for _, test := range SeverityTests {
+		result, err := ParseSeverity(test.in)
+		if (err != nil) != test.err || result != test.exp {
+			t.Errorf("ParseSeverity(%q) didn't match\nEXP: %v, error %v^\nGOT: %v, %v^", test.in, test.exp, test.err, result, err)
+		}
+	}
+	if s := Severity(LAlert).String(); s != "ALERT" {
+		t.Errorf("String didn't match\nEXP: %s^\nGOT: %s^", "ALERT", s)
+	}
+	if s := Severity(12).String(); s != "12" {
+		t.Errorf("String didn't match\nEXP: %s^\nGOT: %s^", "12", s)
+	}
+}
+
+func TestSeverityMarshaling(t *testing.T) {
+	var v struct {
+		A, B, C Severity
+	}
+	if err := json.Unmarshal([]byte(`{"A": "info", "B": 2, "C": "4"}`), &v); err != nil {
+		t.Fatal(err)
+	}
+	if v.A != LInfo || v.B != LCritical || v.C != LWarn {
+		t.Errorf("Unmarshaled severities didn't match: %+v", v)
+	}
+	b, err := json.Marshal(v)
+	exp := `{"A":"INFO","B":"CRITICAL","C":"WARNING"}`
+	if err != nil || string(b) != exp {
+		t.Errorf("Marshaled severities didn't match\nEXP: %s^\nGOT: %s^", exp, b)
+	}
+	if err := json.Unmarshal([]byte(`{"A": true}`), &v); err == nil {
+		t.Error("Unmarshaling a bool didn't fail")
+	}
+
+	verbosity := Severity(LInfo)
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.Var(&verbosity, "v", "verbosity")
+	if err := fs.Parse([]string{"-v=debug"}); err != nil || verbosity != LDebug {
+		t.Errorf("Flag value didn't match\nEXP: %v^\nGOT: %v, %v^", Severity(LDebug), verbosity, err)
+	}
+	relay := New(int(verbosity), "", 0)
+	if relay.Verbosity() != LDebug {
+		t.Errorf("Verbosity didn't match\nEXP: %d^\nGOT: %d^", LDebug, relay.Verbosity())
+	}
+}
@@ -0,0 +1,56 @@
+package relog
+
+import (
+	"context"
+	"reflect"
+)
+
+// Shutdown closes the standard Relay's receivers and every registered receiver, as by Relay.Close, so that
+// buffered messages are written and files and connections are released before the program exits.
+// Each receiver is closed once, even if it is registered under several names or is also a receiver of a registered Relay.
+// If ctx is done first, Shutdown returns ctx.Err() without waiting further; otherwise it returns the first error encountered.
+func Shutdown(ctx context.Context) error {
+	receivers := std.ownReceivers()
+	receivers = receivers[:len(receivers):len(receivers)]
+	for _, name := range Registered() {
+		if rcvr := Lookup(name); rcvr != nil {
+			receivers = append(receivers, rcvr)
+		}
+	}
+	return closeAll(ctx, receivers)
+}
+
+// closeAll closes receivers, as by Relay.Close, returning ctx.Err() if ctx is done first,
+// or otherwise the first error encountered. Relays are closed by closing their own receivers in turn,
+// and receivers held by pointer are closed only the first time they are reached.
+func closeAll(ctx context.Context, receivers []Receiver) error {
+	done := make(chan error, 1)
+	go func() {
+		var err error
+		closed := make(map[Receiver]bool)
+		var closeOnce func(rcvrs []Receiver)
+		closeOnce = func(rcvrs []Receiver) {
+			for _, rcvr := range rcvrs {
+				if reflect.ValueOf(rcvr).Kind() == reflect.Ptr {
+					if closed[rcvr] {
+						continue
+					}
+					closed[rcvr] = true
+				}
+				if r, ok := rcvr.(*Relay); ok {
+					closeOnce(r.ownReceivers())
+				} else if e := closeReceiver(rcvr); e != nil && err == nil {
+					err = e
+				}
+			}
+		}
+		closeOnce(receivers)
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,107 @@
+package relog
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"os"
+	"testing"
+	"time"
+)
+
+// closeRecorder records calls to Flush and Close.
+type closeRecorder struct {
+	*Collector
+	flushes, closes int
+	block           chan struct{} // if not nil, Close waits for it to be closed
+}
+
+func (c *closeRecorder) Flush() error {
+	c.flushes++
+	return c.Collector.Flush()
+}
+
+func (c *closeRecorder) Close() error {
+	c.closes++
+	if c.block != nil {
+		<-c.block
+	}
+	return nil
+}
+
+func TestRelayFlushClose(t *testing.T) {
+	var output bytes.Buffer
+	w := bufio.NewWriter(&output)
+	rec := &closeRecorder{Collector: NewCollector(w, LDebug, "", 0)}
+	inner := New(LDebug, "", 0)
+	inner.AddReceiver(NewAsyncReceiver(rec, 10, Block, 0))
+	relay := New(LDebug, "", 0)
+	relay.AddReceiver(inner)
+
+	relay.Infof("buffered")
+	if err := relay.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	exp := "[INFO] buffered\n"
+	if result := output.String(); result != exp || rec.flushes != 1 {
+		t.Errorf("Flushed output didn't match\nEXP: %s^\nGOT: %s^ after %d flushes", exp, result, rec.flushes)
+	}
+	if err := relay.Close(); err != nil || rec.closes != 1 {
+		t.Errorf("Close didn't close the nested receiver: %v, %d closes", err, rec.closes)
+	}
+}
+
+func TestFatalFlushes(t *testing.T) {
+	var output bytes.Buffer
+	relay := New(LDebug, "", 0)
+	relay.AddReceiver(NewAsyncReceiver(NewCollector(bufio.NewWriter(&output), LDebug, "", 0), 10, Block, 0))
+	code := -1
+	osExit = func(c int) { code = c }
+	defer func() { osExit = os.Exit }()
+
+	relay.Fatalf("stopping")
+	exp := "[EMERGENCY] stopping\n"
+	if result := output.String(); result != exp || code != 1 {
+		t.Errorf("Fatal output didn't match\nEXP: %s^\nGOT: %s^ exit code %d", exp, result, code)
+	}
+}
+
+func TestShutdown(t *testing.T) {
+	out := &closeRecorder{Collector: NewCollector(&bytes.Buffer{}, LDebug, "", 0)}
+	app := New(LDebug, "", 0)
+	app.AddReceiver(out)
+	db := app.Named("db")
+	db.AddReceiver(out)
+	tree := &Tree{Relays: map[string]*Relay{"app": app, "db": db}, Outputs: map[string]Receiver{"out": out}}
+	tree.Register()
+	defer func() {
+		for _, name := range []string{"app", "db", "out"} {
+			Unregister(name)
+		}
+	}()
+
+	if err := Shutdown(context.Background()); err != nil || out.closes != 1 {
+		t.Errorf("Shutdown didn't close the output once: %v, %d closes", err, out.closes)
+	}
+
+	blocked := &closeRecorder{Collector: NewCollector(&bytes.Buffer{}, LDebug, "", 0), block: make(chan struct{})}
+	defer close(blocked.block)
+	Register("out", blocked)
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if err := Shutdown(ctx); err != context.DeadlineExceeded || time.Since(start) > 5*time.Second {
+		t.Errorf("Shutdown error didn't match\nEXP: %v^\nGOT: %v^ after %v", context.DeadlineExceeded, err, time.Since(start))
+	}
+}
+
+func TestShutdownDeadline(t *testing.T) {
+	rec := &closeRecorder{Collector: NewCollector(&bytes.Buffer{}, LDebug, "", 0), block: make(chan struct{})}
+	defer close(rec.block)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := closeAll(ctx, []Receiver{rec}); err != context.DeadlineExceeded {
+		t.Errorf("Shutdown error didn't match\nEXP: %v^\nGOT: %v^", context.DeadlineExceeded, err)
+	}
+}
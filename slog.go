@@ -0,0 +1,205 @@
+package relog
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"runtime"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// slogSeverity maps a slog level to a severity. Levels between slog's named levels map to the next lower
+// severity, with LNotice at LevelInfo+2, and LCritical, LAlert and LEmerg at LevelError+4, +8 and +12.
+func slogSeverity(level slog.Level) int {
+	switch {
+	case level < slog.LevelInfo:
+		return LDebug
+	case level < slog.LevelInfo+2:
+		return LInfo
+	case level < slog.LevelWarn:
+		return LNotice
+	case level < slog.LevelError:
+		return LWarn
+	case level < slog.LevelError+4:
+		return LError
+	case level < slog.LevelError+8:
+		return LCritical
+	case level < slog.LevelError+12:
+		return LAlert
+	}
+	return LEmerg
+}
+
+// slogLevels holds the slog level for each severity, the inverse of slogSeverity.
+var slogLevels = []slog.Level{
+	slog.LevelError + 12, slog.LevelError + 8, slog.LevelError + 4, slog.LevelError,
+	slog.LevelWarn, slog.LevelInfo + 2, slog.LevelInfo, slog.LevelDebug,
+}
+
+// slogLevel maps a severity to a slog level.
+func slogLevel(severity int) slog.Level {
+	return slogLevels[syslogSeverity(severity)]
+}
+
+// SlogHandler is a slog.Handler which logs records via a Relay, so that code using log/slog can share
+// a Relay's receivers. Attributes become fields, with the keys of attributes in groups qualified by the
+// group names, e.g. "req.id". Records' sources are passed on as the entries' callers.
+type SlogHandler struct {
+	relay  *Relay
+	fields []Field // from WithAttrs
+	group  string  // qualifier for subsequent attribute keys, from WithGroup, e.g. "req."
+}
+
+// NewSlogHandler creates a new SlogHandler which logs via r.
+func NewSlogHandler(r *Relay) *SlogHandler {
+	return &SlogHandler{relay: r}
+}
+
+// Enabled reports whether the Relay's verbosity, or any of its vmodule rules, admits the severity of level.
+// Handle applies the rule for the record's caller.
+func (h *SlogHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.relay.settings().anyEnabled(slogSeverity(level))
+}
+
+// Handle logs the record via the Relay, attaching any fields carried by ctx after the record's attributes.
+func (h *SlogHandler) Handle(ctx context.Context, rec slog.Record) error {
+	e := Entry{
+		Time:     rec.Time,
+		Severity: slogSeverity(rec.Level),
+		Message:  rec.Message,
+		Fields:   h.fields,
+	}
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	if rec.NumAttrs() > 0 {
+		fields := make([]Field, 0, len(h.fields)+rec.NumAttrs())
+		fields = append(fields, h.fields...)
+		rec.Attrs(func(a slog.Attr) bool {
+			fields = appendAttr(fields, h.group, a)
+			return true
+		})
+		e.Fields = fields
+	}
+	e.Fields = joinFields(e.Fields, contextFields(ctx))
+	if rec.PC != 0 {
+		frame, _ := runtime.CallersFrames([]uintptr{rec.PC}).Next()
+		e.File, e.Line = frame.File, frame.Line
+	}
+	return h.relay.LogEntry(&e)
+}
+
+// WithAttrs returns a SlogHandler which attaches attrs to every record.
+func (h *SlogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	fields := make([]Field, 0, len(h.fields)+len(attrs))
+	fields = append(fields, h.fields...)
+	for _, a := range attrs {
+		fields = appendAttr(fields, h.group, a)
+	}
+	return &SlogHandler{relay: h.relay, fields: fields, group: h.group}
+}
+
+// WithGroup returns a SlogHandler which qualifies the keys of subsequent attributes with name.
+func (h *SlogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return &SlogHandler{relay: h.relay, fields: h.fields, group: h.group + name + "."}
+}
+
+// appendAttr appends a as one or more fields, flattening groups, following the slog.Handler rules for empty attributes.
+func appendAttr(fields []Field, group string, a slog.Attr) []Field {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return fields
+	}
+	if a.Value.Kind() == slog.KindGroup {
+		if a.Key != "" {
+			group += a.Key + "."
+		}
+		for _, ga := range a.Value.Group() {
+			fields = appendAttr(fields, group, ga)
+		}
+		return fields
+	}
+	return append(fields, Field{group + a.Key, a.Value.Any()})
+}
+
+// SlogReceiver passes log messages to a slog.Handler, so that a Relay can log to handlers written for log/slog.
+// Fields become attributes, and severities map to slog levels as LDebug to LevelDebug, LInfo to LevelInfo,
+// LNotice to LevelInfo+2, LWarn to LevelWarn, LError to LevelError, and LCritical, LAlert and LEmerg
+// to LevelError+4, +8 and +12. The handler's Enabled method is honoured as well as the receiver's verbosity.
+// Output flags are ignored, as the handler determines the layout.
+// SlogReceiver implements the Receiver interface.
+type SlogReceiver struct {
+	handler   slog.Handler
+	verbosity atomic.Int32
+	prefix    atomic.Value // string
+}
+
+// NewSlogReceiver creates a new SlogReceiver which passes messages to h.
+func NewSlogReceiver(h slog.Handler, verbosity int) *SlogReceiver {
+	s := &SlogReceiver{handler: h}
+	s.verbosity.Store(int32(verbosity))
+	s.prefix.Store("")
+	return s
+}
+
+// handle builds a record for msg and fields and passes it to the handler.
+func (s *SlogReceiver) handle(severity int, calldepth int, msg string, fields []Field) error {
+	if int(s.verbosity.Load()) < severity {
+		return nil
+	}
+	level := slogLevel(severity)
+	ctx := context.Background()
+	if !s.handler.Enabled(ctx, level) {
+		return nil
+	}
+	var pcs [1]uintptr
+	runtime.Callers(calldepth+1, pcs[:]) // +1 for runtime.Callers itself
+	rec := slog.NewRecord(time.Now(), level, s.prefix.Load().(string)+strings.TrimSuffix(msg, "\n"), pcs[0])
+	for _, f := range fields {
+		rec.AddAttrs(slog.Any(f.Key, f.Value))
+	}
+	return s.handler.Handle(ctx, rec)
+}
+
+// SetOutput is a null function for interface compatibility; the handler determines the output.
+func (s *SlogReceiver) SetOutput(w io.Writer) {}
+
+// SetFlags is a null function for interface compatibility; the handler determines the layout.
+func (s *SlogReceiver) SetFlags(flag int, maskOp int) {}
+
+// SetPrefix sets the value prepended to each message.
+func (s *SlogReceiver) SetPrefix(prefix string) { s.prefix.Store(prefix) }
+
+// SetVerbosity sets the SlogReceiver's verbosity. Messages of lower priority than the verbosity are not logged.
+func (s *SlogReceiver) SetVerbosity(verbosity int) { s.verbosity.Store(int32(verbosity)) }
+
+// Output passes s to the handler at the level of severity Notice.
+func (s *SlogReceiver) Output(calldepth int, str string) error {
+	return s.handle(LNotice, calldepth+1, str, nil)
+}
+
+// Log generates the message and passes it to the handler.
+func (s *SlogReceiver) Log(severity int, calldepth int, v ...interface{}) {
+	s.handle(severity, calldepth+1, fmt.Sprint(v...), nil)
+}
+
+// Logf generates the message and passes it to the handler.
+func (s *SlogReceiver) Logf(severity int, calldepth int, format string, v ...interface{}) {
+	s.handle(severity, calldepth+1, fmt.Sprintf(format, v...), nil)
+}
+
+// Logln generates the message and passes it to the handler.
+func (s *SlogReceiver) Logln(severity int, calldepth int, v ...interface{}) {
+	s.handle(severity, calldepth+1, fmt.Sprintln(v...), nil)
+}
+
+// Logw passes msg to the handler, with fields as attributes.
+func (s *SlogReceiver) Logw(severity int, calldepth int, msg string, fields []Field) {
+	s.handle(severity, calldepth+1, msg, fields)
+}
@@ -0,0 +1,39 @@
+package relog
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestSlogHandler(t *testing.T) {
+	var output bytes.Buffer
+	relay := New(LInfo, "", 0)
+	relay.AddWriter(&output, LDebug, "", Lshortfile)
+	logger := slog.New(NewSlogHandler(relay)).With("component", "db").WithGroup("req")
+
+	logger.Debug("dropped")
+	logger.Warn("slog", "id", 7, slog.Group("user", "name", "x"), slog.Group("empty"))
+	result := output.String()
+	if exp := "[WARNING] slog component=db req.id=7 req.user.name=x\n"; !strings.HasSuffix(result, exp) {
+		t.Errorf("SlogHandler messages didn't match\nEXP: %s^\nGOT: %s^", exp, result)
+	} else if !strings.HasPrefix(result, "slog_test.go:") {
+		t.Errorf("SlogHandler messages have wrong caller\nGOT: %s^", result)
+	}
+}
+
+func TestSlogReceiver(t *testing.T) {
+	var output bytes.Buffer
+	relay := New(LDebug, "", 0)
+	relay.AddReceiver(NewSlogReceiver(slog.NewTextHandler(&output, &slog.HandlerOptions{AddSource: true}), LDebug))
+
+	relay.Debug("dropped by the handler")
+	relay.Errorw("slog", "id", 7)
+	result := output.String()
+	if exp := "msg=slog id=7\n"; !strings.HasSuffix(result, exp) || !strings.Contains(result, "level=ERROR") {
+		t.Errorf("SlogReceiver messages didn't match\nEXP: %s^\nGOT: %s^", exp, result)
+	} else if !strings.Contains(result, "slog_test.go:") {
+		t.Errorf("SlogReceiver messages have wrong source\nGOT: %s^", result)
+	}
+}
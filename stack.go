@@ -0,0 +1,49 @@
+package relog
+
+import (
+	"math"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// NoStacks is the stack severity at which no stacks are captured; it is the default for Relays and Collectors.
+const NoStacks = math.MinInt32
+
+// maxStackFrames is the maximum number of frames captured in a stack.
+const maxStackFrames = 64
+
+// stack returns the calling goroutine's stack, starting from the frame calldepth frames above its caller,
+// with each frame's function followed by its tab indented file and line, as in a panic's stack trace:
+//
+//	main.handle(...)
+//		/src/main.go:23
+func stack(calldepth int) string {
+	pcs := make([]uintptr, maxStackFrames)
+	n := runtime.Callers(calldepth+2, pcs) // +2 for runtime.Callers and this frame
+	frames := runtime.CallersFrames(pcs[:n])
+	var b strings.Builder
+	for {
+		frame, more := frames.Next()
+		if frame.Function == "runtime.goexit" {
+			break
+		}
+		if b.Len() > 0 {
+			b.WriteByte('\n')
+		}
+		b.WriteString(frame.Function + "(...)\n\t" + frame.File + ":" + strconv.Itoa(frame.Line))
+		if !more {
+			break
+		}
+	}
+	return b.String()
+}
+
+// stackField returns fields followed by the stack, if any, as the field "stack", for receivers that take fields
+// rather than entries. fields is not modified.
+func stackField(fields []Field, stack string) []Field {
+	if stack == "" {
+		return fields
+	}
+	return append(fields[:len(fields):len(fields)], Field{"stack", stack})
+}
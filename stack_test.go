@@ -0,0 +1,83 @@
+package relog
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// stackTop matches the first frame of a stack captured in a function of this file.
+const stackTop = `[\w./]*relog\.TestStack\w*\(\.\.\.\)\n\t[^\n]*stack_test\.go:\d+`
+
+func TestStackCollector(t *testing.T) {
+	var output bytes.Buffer
+	c := NewCollector(&output, LDebug, "", Lshortfile)
+	c.Log(LError, 1, "no stack")
+	c.SetStackSeverity(LError)
+	c.Logf(LWarn, 1, "still %s", "none")
+	c.Log(LCritical, 1, "captured")
+	result := output.String()
+	exp := `^stack_test.go:\d+: \[ERROR\] no stack\nstack_test.go:\d+: \[WARNING\] still none\n` +
+		`stack_test.go:\d+: \[CRITICAL\] captured\n` + stackTop + `\n`
+	if !regexp.MustCompile(exp).MatchString(result) {
+		t.Errorf("Collector stack didn't match\nEXP: %s^\nGOT: %s^", exp, result)
+	}
+	if strings.Contains(result, "collector.go") {
+		t.Errorf("Collector stack includes relog's frames\nGOT: %s^", result)
+	}
+}
+
+func TestStackRelay(t *testing.T) {
+	var output, structured bytes.Buffer
+	relay := New(LDebug, "", 0)
+	relay.AddWriter(&output, LDebug, "", 0)
+	relay.AddReceiver(NewJSONCollector(&structured, LDebug, "", 0))
+	child := relay.Named("stack")
+	child.Warn("no stack")
+	relay.SetStackSeverity(LWarn)
+	child.Warnw("captured", "id", 7)
+	child.SetStackSeverity(NoStacks)
+	child.Error("no stack")
+
+	result := output.String()
+	exp := `^\[WARNING\] no stack logger=stack\n\[WARNING\] captured logger=stack id=7\n` + stackTop + `(?:\n[^[][^\n]*)*\n\[ERROR\] no stack logger=stack\n$`
+	if !regexp.MustCompile(exp).MatchString(result) {
+		t.Errorf("Relay stack didn't match\nEXP: %s^\nGOT: %s^", exp, result)
+	}
+	if strings.Contains(result, "relay.go") {
+		t.Errorf("Relay stack includes relog's frames\nGOT: %s^", result)
+	}
+
+	lines := strings.Split(strings.TrimSpace(structured.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("JSON entries didn't match\nGOT: %s^", structured.String())
+	}
+	var entry map[string]interface{}
+	if err := json.Unmarshal([]byte(lines[1]), &entry); err != nil {
+		t.Fatal(err)
+	}
+	if s, _ := entry["stack"].(string); !regexp.MustCompile(`^` + stackTop).MatchString(s) {
+		t.Errorf("JSON stack didn't match\nEXP: %s^\nGOT: %s^", stackTop, s)
+	}
+	if strings.Contains(lines[0], `"stack":`) || strings.Contains(lines[2], `"stack":`) {
+		t.Errorf("JSON entries have unexpected stacks\nGOT: %s^", structured.String())
+	}
+}
+
+func TestStackField(t *testing.T) {
+	var output bytes.Buffer
+	relay := New(LDebug, "", 0)
+	relay.AddReceiver(NewSlogReceiver(slog.NewJSONHandler(&output, nil), LDebug))
+	relay.SetStackSeverity(LError)
+	relay.Errorf("failed %d", 1)
+	var entry map[string]interface{}
+	if err := json.Unmarshal(output.Bytes(), &entry); err != nil {
+		t.Fatal(err)
+	}
+	if s, _ := entry["stack"].(string); !regexp.MustCompile(`^` + stackTop).MatchString(s) {
+		t.Errorf("slog stack didn't match\nEXP: %s^\nGOT: %s^", stackTop, s)
+	}
+}
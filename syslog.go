@@ -0,0 +1,424 @@
+package relog
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Syslog facilities, as defined by rfc3164.
+const (
+	FacilityKern = iota
+	FacilityUser
+	FacilityMail
+	FacilityDaemon
+	FacilityAuth
+	FacilitySyslog
+	FacilityLpr
+	FacilityNews
+	FacilityUucp
+	FacilityCron
+	FacilityAuthpriv
+	FacilityFtp
+	_
+	_
+	_
+	_
+	FacilityLocal0
+	FacilityLocal1
+	FacilityLocal2
+	FacilityLocal3
+	FacilityLocal4
+	FacilityLocal5
+	FacilityLocal6
+	FacilityLocal7
+)
+
+// Syslog message formats.
+const (
+	RFC3164 = iota // BSD syslog: <PRI>Mmm dd hh:mm:ss HOSTNAME TAG[PID]: MSG
+	RFC5424        // <PRI>1 TIMESTAMP HOSTNAME APP-NAME PROCID MSGID STRUCTURED-DATA MSG
+)
+
+// syslogSDID is the structured data ID under which fields are sent in RFC5424 messages.
+// 32473 is the private enterprise number reserved for documentation by rfc5612.
+const syslogSDID = "relog@32473"
+
+// errSyslogClosed is returned for messages sent to a SyslogReceiver after it is closed.
+var errSyslogClosed = errors.New("relog: syslog receiver closed")
+
+// syslogDialTimeout bounds each attempt to connect to the syslog daemon.
+const syslogDialTimeout = 2 * time.Second
+
+// Bounds of the wait after a failed attempt to connect to the syslog daemon before the next.
+const (
+	syslogMinBackoff = 100 * time.Millisecond
+	syslogMaxBackoff = 30 * time.Second
+)
+
+// localSyslogPaths are tried in order when connecting to the local syslog daemon.
+var localSyslogPaths = []string{"/dev/log", "/var/run/syslog", "/var/run/log"}
+
+// SyslogReceiver sends log messages to a syslog daemon, mapping severities LEmerg through LDebug
+// directly onto syslog severities, and custom severities onto the syslog severities they were defined with.
+// SyslogReceiver implements the Receiver interface.
+// Failed writes cause the connection to be re-established and the write retried once;
+// if the daemon is still unreachable, the message is dropped, as are further messages until a backoff period
+// has passed, so that logging doesn't wait on a connection attempt for every message while the daemon is down.
+type SyslogReceiver struct {
+	mu            sync.Mutex
+	network       string
+	raddr         string
+	conn          net.Conn
+	dialErr       error         // the error of the last failed connection attempt
+	backoff       time.Duration // the wait after the last failed connection attempt, doubling with each failure
+	retryAt       time.Time     // messages are dropped until then, rather than trying to reconnect
+	now           func() time.Time
+	closed        bool
+	facility      int
+	format        int
+	octetCounting bool
+	hostname      string
+	tag           string
+	prefix        string
+	flag          int
+	verbosity     int
+}
+
+// NewSyslogReceiver connects to the syslog daemon at raddr over network ("udp", "tcp", "unix" or "unixgram"),
+// and creates a SyslogReceiver that logs messages with the given facility and tag, in RFC3164 format.
+// If network is empty, NewSyslogReceiver connects to the local syslog daemon, e.g. /dev/log.
+// If tag is empty, the program's name is used.
+func NewSyslogReceiver(network, raddr string, facility int, verbosity int, tag string) (*SyslogReceiver, error) {
+	if facility < FacilityKern || facility > FacilityLocal7 {
+		return nil, fmt.Errorf("relog: invalid syslog facility %d", facility)
+	}
+	if tag == "" {
+		tag = filepath.Base(os.Args[0])
+	}
+	hostname, _ := os.Hostname()
+	s := &SyslogReceiver{
+		network:   network,
+		raddr:     raddr,
+		facility:  facility,
+		format:    RFC3164,
+		hostname:  hostname,
+		tag:       tag,
+		verbosity: verbosity,
+		now:       time.Now,
+	}
+	if err := s.connect(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// connect (re)establishes the connection to the syslog daemon. The caller must hold s.mu.
+// After a failed attempt, connect returns the same error without trying again until the backoff period has passed.
+func (s *SyslogReceiver) connect() error {
+	if s.conn != nil {
+		s.conn.Close()
+		s.conn = nil
+	}
+	if s.closed {
+		return errSyslogClosed
+	}
+	now := s.now()
+	if s.dialErr != nil && now.Before(s.retryAt) {
+		return s.dialErr
+	}
+	conn, err := s.dial()
+	if err != nil {
+		s.backoff *= 2
+		if s.backoff < syslogMinBackoff {
+			s.backoff = syslogMinBackoff
+		} else if s.backoff > syslogMaxBackoff {
+			s.backoff = syslogMaxBackoff
+		}
+		s.dialErr, s.retryAt = err, now.Add(s.backoff)
+		return err
+	}
+	s.conn, s.dialErr, s.backoff = conn, nil, 0
+	return nil
+}
+
+// dial connects to the syslog daemon.
+func (s *SyslogReceiver) dial() (net.Conn, error) {
+	if s.network != "" {
+		return net.DialTimeout(s.network, s.raddr, syslogDialTimeout)
+	}
+	paths := localSyslogPaths
+	if s.raddr != "" {
+		paths = []string{s.raddr}
+	}
+	for _, path := range paths {
+		for _, network := range []string{"unixgram", "unix"} {
+			if conn, err := net.DialTimeout(network, path, syslogDialTimeout); err == nil {
+				return conn, nil
+			}
+		}
+	}
+	return nil, errors.New("relog: unable to connect to local syslog daemon")
+}
+
+// local reports whether the receiver is connected to a syslog daemon on this host via a unix socket.
+func (s *SyslogReceiver) local() bool {
+	return s.network == "" || strings.HasPrefix(s.network, "unix")
+}
+
+// stream reports whether the receiver's transport is a stream, requiring messages to be framed.
+func (s *SyslogReceiver) stream() bool {
+	if s.conn == nil {
+		return false
+	}
+	switch s.conn.RemoteAddr().Network() {
+	case "tcp", "tcp4", "tcp6", "unix":
+		return true
+	}
+	return false
+}
+
+// SetFormat sets the syslog message format, RFC3164 or RFC5424.
+func (s *SyslogReceiver) SetFormat(format int) {
+	s.mu.Lock()
+	s.format = format
+	s.mu.Unlock()
+}
+
+// SetOctetCounting sets whether messages sent over stream transports are framed by prefixing
+// their length (rfc6587 octet counting) rather than terminated with a newline.
+func (s *SyslogReceiver) SetOctetCounting(on bool) {
+	s.mu.Lock()
+	s.octetCounting = on
+	s.mu.Unlock()
+}
+
+// SetHostname sets the hostname reported in each message.
+func (s *SyslogReceiver) SetHostname(hostname string) {
+	s.mu.Lock()
+	s.hostname = hostname
+	s.mu.Unlock()
+}
+
+// SetFlags sets the SyslogReceiver's flag via a masking operation. Only Lshortfile and Llongfile
+// affect the message; the syslog header always carries the timestamp.
+func (s *SyslogReceiver) SetFlags(flag int, maskOp int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch maskOp {
+	case NONE:
+		s.flag = flag
+	case AND:
+		s.flag = s.flag & flag
+	case OR:
+		s.flag = s.flag | flag
+	case XOR:
+		s.flag = s.flag ^ flag
+	case ANDNOT:
+		s.flag = s.flag &^ flag
+	}
+}
+
+// SetPrefix sets the value prepended to each message.
+func (s *SyslogReceiver) SetPrefix(prefix string) {
+	s.mu.Lock()
+	s.prefix = prefix
+	s.mu.Unlock()
+}
+
+// SetOutput is a null function for interface compatibility; the SyslogReceiver always writes to its connection.
+func (s *SyslogReceiver) SetOutput(w io.Writer) {}
+
+// SetVerbosity sets the SyslogReceiver's verbosity. Messages of lower priority than the verbosity are not logged.
+func (s *SyslogReceiver) SetVerbosity(verbosity int) {
+	s.mu.Lock()
+	s.verbosity = verbosity
+	s.mu.Unlock()
+}
+
+// Close closes the connection to the syslog daemon. Messages logged after Close are dropped.
+func (s *SyslogReceiver) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	if s.conn == nil {
+		return nil
+	}
+	err := s.conn.Close()
+	s.conn = nil
+	return err
+}
+
+// Output sends s at severity Notice.
+func (s *SyslogReceiver) Output(calldepth int, str string) error {
+	return s.write(calldepth+1, LNotice, str, nil)
+}
+
+// Log generates the message and sends it to the syslog daemon.
+func (s *SyslogReceiver) Log(severity int, calldepth int, v ...interface{}) {
+	if s.enabled(severity) {
+		s.write(calldepth+1, severity, fmt.Sprint(v...), nil)
+	}
+}
+
+// Logf generates the message and sends it to the syslog daemon.
+func (s *SyslogReceiver) Logf(severity int, calldepth int, format string, v ...interface{}) {
+	if s.enabled(severity) {
+		s.write(calldepth+1, severity, fmt.Sprintf(format, v...), nil)
+	}
+}
+
+// Logln generates the message and sends it to the syslog daemon.
+func (s *SyslogReceiver) Logln(severity int, calldepth int, v ...interface{}) {
+	if s.enabled(severity) {
+		s.write(calldepth+1, severity, fmt.Sprintln(v...), nil)
+	}
+}
+
+// Logw sends msg to the syslog daemon, with fields as RFC5424 structured data, or appended as key=value pairs for RFC3164.
+func (s *SyslogReceiver) Logw(severity int, calldepth int, msg string, fields []Field) {
+	if s.enabled(severity) {
+		s.write(calldepth+1, severity, msg, fields)
+	}
+}
+
+func (s *SyslogReceiver) enabled(severity int) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.verbosity >= severity
+}
+
+// write builds the Entry for msg and fields, and sends it.
+func (s *SyslogReceiver) write(calldepth int, severity int, msg string, fields []Field) error {
+	e := Entry{Time: time.Now(), Severity: severity, Message: msg, Fields: fields}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.flag&(Lshortfile|Llongfile) != 0 {
+		e.File, e.Line = caller(calldepth, s.flag)
+	}
+	return s.send(&e)
+}
+
+// LogEntry sends e, whose caller has already been resolved, to the syslog daemon.
+func (s *SyslogReceiver) LogEntry(e *Entry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.verbosity < e.Severity {
+		return nil
+	}
+	entry := *e
+	entry.setFlag(s.flag)
+	return s.send(&entry)
+}
+
+// send formats and sends a single entry, reconnecting and retrying once on failure. The caller must hold s.mu.
+func (s *SyslogReceiver) send(e *Entry) error {
+	if s.closed {
+		return errSyslogClosed
+	}
+	msg := e.Message
+	if e.File != "" {
+		msg = e.File + ":" + strconv.Itoa(e.Line) + ": " + msg
+	}
+	msg = strings.TrimRight(s.prefix+msg, "\n")
+	severity := syslogSeverity(e.Severity)
+	fields := stackField(e.Fields, e.Stack)
+	frame := s.frame(e.Time, s.facility*8+severity, msg, fields)
+
+	var err error
+	if s.conn != nil {
+		if _, err = s.conn.Write(frame); err == nil {
+			return nil
+		}
+	}
+	if err = s.connect(); err != nil {
+		return err
+	}
+	frame = s.frame(e.Time, s.facility*8+severity, msg, fields) // framing may depend on the new transport
+	_, err = s.conn.Write(frame)
+	return err
+}
+
+// frame returns the complete, framed syslog message. The caller must hold s.mu.
+func (s *SyslogReceiver) frame(t time.Time, pri int, msg string, fields []Field) []byte {
+	var b strings.Builder
+	b.WriteString("<" + strconv.Itoa(pri) + ">")
+	pid := strconv.Itoa(os.Getpid())
+	switch s.format {
+	case RFC5424:
+		b.WriteString("1 ")
+		b.WriteString(t.Format("2006-01-02T15:04:05.000000Z07:00"))
+		b.WriteString(" " + syslogHeaderField(s.hostname, 255))
+		b.WriteString(" " + syslogHeaderField(s.tag, 48))
+		b.WriteString(" " + pid + " - ")
+		b.WriteString(syslogStructuredData(fields))
+		if msg != "" {
+			b.WriteString(" " + msg)
+		}
+	default:
+		b.WriteString(t.Format(time.Stamp))
+		if !s.local() {
+			b.WriteString(" " + s.hostname)
+		}
+		b.WriteString(" " + s.tag + "[" + pid + "]: ")
+		b.WriteString(msg + formatFields(fields))
+	}
+	if !s.stream() {
+		return []byte(b.String())
+	}
+	if s.octetCounting {
+		return []byte(strconv.Itoa(b.Len()) + " " + b.String())
+	}
+	return []byte(b.String() + "\n")
+}
+
+// syslogHeaderField returns v as an RFC5424 header field: printable ASCII without spaces, truncated to max, or "-" if empty.
+func syslogHeaderField(v string, max int) string {
+	v = strings.Map(func(r rune) rune {
+		if r < 33 || r > 126 {
+			return '_'
+		}
+		return r
+	}, v)
+	if v == "" {
+		return "-"
+	}
+	if len(v) > max {
+		v = v[:max]
+	}
+	return v
+}
+
+// syslogStructuredData renders fields as a single RFC5424 SD-ELEMENT, or "-" if there are none.
+func syslogStructuredData(fields []Field) string {
+	if len(fields) == 0 {
+		return "-"
+	}
+	var b strings.Builder
+	b.WriteString("[" + syslogSDID)
+	for _, f := range fields {
+		name := strings.Map(func(r rune) rune {
+			if r < 33 || r > 126 || r == '=' || r == ']' || r == '"' {
+				return '_'
+			}
+			return r
+		}, f.Key)
+		if name == "" {
+			name = "_"
+		}
+		if len(name) > 32 {
+			name = name[:32]
+		}
+		value := strings.NewReplacer(`\`, `\\`, `"`, `\"`, `]`, `\]`).Replace(fmt.Sprint(f.Value))
+		b.WriteString(" " + name + `="` + value + `"`)
+	}
+	b.WriteString("]")
+	return b.String()
+}
@@ -0,0 +1,144 @@
+package relog
+
+import (
+	"net"
+	"regexp"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestSyslogUDP(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	s, err := NewSyslogReceiver("udp", conn.LocalAddr().String(), FacilityLocal0, LInfo, "relog")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	s.SetHostname("host")
+
+	buf := make([]byte, 2048)
+	read := func() string {
+		conn.SetReadDeadline(time.Now().Add(time.Second))
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(buf[:n])
+	}
+
+	s.Log(LDebug, 1, "dropped")
+	s.Log(LError, 1, "syslog")
+	exp := regexp.MustCompile(`^<131>\w{3} [ \d]\d \d\d:\d\d:\d\d host relog\[\d+\]: syslog$`)
+	if result := read(); !exp.MatchString(result) {
+		t.Errorf("Syslog RFC3164 message didn't match\nEXP: %s^\nGOT: %s^", exp, result)
+	}
+
+	s.SetFormat(RFC5424)
+	s.Logw(LWarn, 1, "syslog", []Field{{"id", `a"b]`}, {"n", 1}})
+	exp = regexp.MustCompile(`^<132>1 \S+ host relog \d+ - \[relog@32473 id="a\\"b\\]" n="1"\] syslog$`)
+	if result := read(); !exp.MatchString(result) {
+		t.Errorf("Syslog RFC5424 message didn't match\nEXP: %s^\nGOT: %s^", exp, result)
+	}
+}
+
+func TestSyslogTCPReconnect(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	received := make(chan string)
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go func(conn net.Conn) {
+				defer conn.Close()
+				buf := make([]byte, 2048)
+				for {
+					n, err := conn.Read(buf)
+					if n > 0 {
+						received <- string(buf[:n])
+					}
+					if err != nil {
+						return
+					}
+				}
+			}(conn)
+		}
+	}()
+
+	s, err := NewSyslogReceiver("tcp", l.Addr().String(), FacilityUser, LDebug, "relog")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	s.SetFormat(RFC5424)
+	s.SetOctetCounting(true)
+
+	s.Log(LInfo, 1, "first\n")
+	s.Log(LInfo, 1, "second\n")
+	s.conn.Close() // force the next write to fail and reconnect
+	s.Log(LInfo, 1, "third\n")
+
+	frame := regexp.MustCompile(`(\d+) (<14>1 [^<]* (first|second|third))`)
+	var got string
+	timeout := time.After(time.Second)
+	for len(frame.FindAllString(got, -1)) < 3 {
+		select {
+		case chunk := <-received:
+			got += chunk
+		case <-timeout:
+			t.Fatalf("timed out waiting for syslog messages, got %q", got)
+		}
+	}
+	for _, m := range frame.FindAllStringSubmatch(got, -1) {
+		if m[1] != strconv.Itoa(len(m[2])) {
+			t.Errorf("Syslog octet count %s doesn't match message length %d: %q", m[1], len(m[2]), m[2])
+		}
+	}
+}
+
+func TestSyslogBackoff(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := l.Addr().String()
+	s, err := NewSyslogReceiver("tcp", addr, FacilityUser, LDebug, "relog")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	now := time.Date(2009, 1, 23, 1, 23, 23, 0, time.UTC)
+	s.now = func() time.Time { return now }
+
+	l.Close()
+	s.conn.Close() // force the next write to fail, and reconnecting to fail
+	if err := s.Output(1, "refused"); err == nil {
+		t.Fatal("Syslog write succeeded with the daemon down")
+	}
+	if l, err = net.Listen("tcp", addr); err != nil {
+		t.Skip("can't listen again on", addr, err)
+	}
+	defer l.Close()
+	if err := s.Output(1, "dropped"); err == nil {
+		t.Error("Syslog reconnected before the backoff period had passed")
+	}
+	now = now.Add(syslogMinBackoff)
+	if err := s.Output(1, "sent"); err != nil {
+		t.Errorf("Syslog didn't reconnect after the backoff period: %v", err)
+	}
+
+	s.Close()
+	if err := s.Output(1, "closed"); err != errSyslogClosed || s.conn != nil {
+		t.Errorf("Syslog write after Close didn't fail\nEXP: %v^\nGOT: %v^", errSyslogClosed, err)
+	}
+}
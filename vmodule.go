@@ -0,0 +1,164 @@
+package relog
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// vmoduleRule sets the verbosity for callers whose files match pattern.
+type vmoduleRule struct {
+	pattern   string
+	verbosity int
+}
+
+// match reports whether file matches the rule's pattern, comparing the pattern with as many trailing elements
+// of file as it has, so that "db/*" matches any file in a directory named db, and "http/server.go" matches
+// server.go in a directory named http. The pattern may omit the ".go" extension.
+func (rule vmoduleRule) match(file string) bool {
+	elems := strings.Split(filepath.ToSlash(file), "/")
+	n := strings.Count(rule.pattern, "/") + 1
+	if len(elems) < n {
+		return false
+	}
+	tail := strings.Join(elems[len(elems)-n:], "/")
+	if ok, _ := path.Match(rule.pattern, tail); ok {
+		return true
+	}
+	ok, _ := path.Match(rule.pattern, strings.TrimSuffix(tail, ".go"))
+	return ok
+}
+
+// vmodule holds a parsed vmodule spec, and the verbosity it gives each call site seen so far.
+type vmodule struct {
+	spec  string
+	rules []vmoduleRule
+	sites sync.Map // program counter to verbosity, or -1 if no rule matches
+}
+
+// parseVModule parses a comma separated list of pattern=severity rules, e.g. "db/*=7,http/server.go=info".
+func parseVModule(spec string) (*vmodule, error) {
+	vm := &vmodule{spec: spec}
+	for _, rule := range strings.Split(spec, ",") {
+		rule = strings.TrimSpace(rule)
+		if rule == "" {
+			continue
+		}
+		i := strings.LastIndex(rule, "=")
+		if i <= 0 {
+			return nil, fmt.Errorf("relog: invalid vmodule rule %q", rule)
+		}
+		pattern := rule[:i]
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("relog: invalid vmodule pattern %q", pattern)
+		}
+		verbosity, err := ParseSeverity(rule[i+1:])
+		if err != nil {
+			return nil, err
+		}
+		vm.rules = append(vm.rules, vmoduleRule{pattern, int(verbosity)})
+	}
+	return vm, nil
+}
+
+// fileVerbosity returns the verbosity of the first rule matching file, or -1 if none does.
+func (vm *vmodule) fileVerbosity(file string) int {
+	for _, rule := range vm.rules {
+		if rule.match(file) {
+			return rule.verbosity
+		}
+	}
+	return -1
+}
+
+// siteVerbosity returns the verbosity for the call site at pc, or -1 if no rule matches it.
+func (vm *vmodule) siteVerbosity(pc uintptr) int {
+	if v, ok := vm.sites.Load(pc); ok {
+		return v.(int)
+	}
+	frame, _ := runtime.CallersFrames([]uintptr{pc}).Next()
+	verbosity := vm.fileVerbosity(frame.File)
+	vm.sites.Store(pc, verbosity)
+	return verbosity
+}
+
+// SetVModule sets per-file verbosities for messages logged via the Relay, overriding its verbosity
+// for callers whose files match. The spec is a comma separated list of pattern=severity rules, such as
+// "db/*=7,http/server.go=6", in which patterns are matched against the trailing elements of the caller's path,
+// as by path.Match, and severities are given by name or number. The first matching rule applies.
+// The verbosity for each call site is cached, so disabled messages remain cheap. An empty spec removes the rules.
+// Receivers still apply their own verbosities to the messages the Relay forwards.
+func SetVModule(spec string) error { return std.SetVModule(spec) }
+func (r *Relay) SetVModule(spec string) error {
+	vm, err := parseVModule(spec)
+	if err != nil {
+		return err
+	}
+	if len(vm.rules) == 0 {
+		vm = nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.update(func(c *relayConfig) { c.vmodule, c.own = vm, c.own|ownVModule })
+	return nil
+}
+
+// vmoduleRules returns the Relay's vmodule rules, or its parent's if it is a named child without its own.
+func (r *Relay) vmoduleRules() *vmodule {
+	if r.inherits(ownVModule) {
+		return r.parent.vmoduleRules()
+	}
+	return r.cfg.Load().vmodule
+}
+
+// VModule returns the Relay's vmodule spec.
+func VModule() string { return std.VModule() }
+func (r *Relay) VModule() string {
+	if vm := r.vmoduleRules(); vm != nil {
+		return vm.spec
+	}
+	return ""
+}
+
+// enabled reports whether messages at severity from the caller calldepth frames above the caller of enabled
+// are logged under the settings, applying any vmodule rule for the call site.
+func (c *relayConfig) enabled(severity int, calldepth int) bool {
+	if c.vmodule == nil {
+		return c.verbosity >= severity
+	}
+	var pcs [1]uintptr
+	runtime.Callers(calldepth+2, pcs[:]) // +2 for runtime.Callers and this frame
+	if verbosity := c.vmodule.siteVerbosity(pcs[0]); verbosity >= 0 {
+		return verbosity >= severity
+	}
+	return c.verbosity >= severity
+}
+
+// anyEnabled reports whether messages at severity from any call site are logged under the settings,
+// by their verbosity or one of their vmodule rules.
+func (c *relayConfig) anyEnabled(severity int) bool {
+	if c.verbosity >= severity {
+		return true
+	}
+	if c.vmodule != nil {
+		for _, rule := range c.vmodule.rules {
+			if rule.verbosity >= severity {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// entryEnabled reports whether e is logged under the settings, applying any vmodule rule for its caller.
+func (c *relayConfig) entryEnabled(e *Entry) bool {
+	if c.vmodule != nil && e.File != "" {
+		if verbosity := c.vmodule.fileVerbosity(e.File); verbosity >= 0 {
+			return verbosity >= e.Severity
+		}
+	}
+	return c.verbosity >= e.Severity
+}
@@ -0,0 +1,91 @@
+package relog
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"testing"
+)
+
+var VModuleMatchTests = []struct {
+	pattern string
+	file    string
+	exp     bool
+}{
+	{"db/*", "/src/app/db/conn.go", true},
+	{"db/*", "/src/app/dbx/conn.go", false},
+	{"http/server.go", "/src/app/http/server.go", true},
+	{"http/server.go", "/src/app/http/client.go", false},
+	{"server", "/src/app/http/server.go", true},
+	{"serv*", "/src/app/http/server.go", true},
+	{"app/*/server.go", "/src/app/http/server.go", true},
+	{"a/b/c/d.go", "c/d.go", false},
+}
+
+func TestVModuleMatch(t *testing.T) {
+	for _, test := range VModuleMatchTests {
+		if result := (vmoduleRule{test.pattern, LDebug}).match(test.file); result != test.exp {
+			t.Errorf("%s match of %s didn't match\nEXP: %v^\nGOT: %v^", test.pattern, test.file, test.exp, result)
+		}
+	}
+}
+
+func TestRelayVModule(t *testing.T) {
+	var output bytes.Buffer
+	relay := New(LInfo, "", 0)
+	relay.AddWriter(&output, LDebug, "", 0)
+
+	logAll := func() {
+		relay.Debugf("debug")
+		relay.Infow("info")
+		relay.With().Debugln("derived")
+	}
+	logAll()
+	if err := relay.SetVModule("other.go=debug, vmodule_test=debug"); err != nil {
+		t.Fatal(err)
+	}
+	logAll()
+	relay.SetVModule("vmodule_test.go=error")
+	logAll()
+	relay.SetVModule("")
+	logAll()
+
+	exp := "[INFO] info\n" +
+		"[DEBUG] debug\n[INFO] info\n[DEBUG] derived\n" +
+		"[INFO] info\n"
+	if result := output.String(); result != exp {
+		t.Errorf("VModule output didn't match\nEXP: %s^\nGOT: %s^", exp, result)
+	}
+	if spec := relay.VModule(); spec != "" {
+		t.Errorf("VModule didn't match\nEXP: %s^\nGOT: %s^", "", spec)
+	}
+	for _, spec := range []string{"db/*", "db/*=loud", "[=7"} {
+		if err := relay.SetVModule(spec); err == nil {
+			t.Errorf("SetVModule(%q) didn't fail", spec)
+		}
+	}
+}
+
+func TestVModuleCtxSlog(t *testing.T) {
+	var output bytes.Buffer
+	relay := New(LInfo, "", 0)
+	relay.AddWriter(&output, LDebug, "", 0)
+	logger := slog.New(NewSlogHandler(relay))
+	ctx := ContextWith(context.Background(), "id", 7)
+
+	logAll := func() {
+		relay.DebugCtx(ctx, "ctx")
+		relay.DebugfCtx(context.Background(), "ctx%s", "f")
+		logger.Debug("slog")
+	}
+	logAll()
+	relay.SetVModule("vmodule_test=debug")
+	logAll()
+	relay.SetVModule("other.go=debug")
+	logAll()
+
+	exp := "[DEBUG] ctx id=7\n[DEBUG] ctxf\n[DEBUG] slog\n"
+	if result := output.String(); result != exp {
+		t.Errorf("VModule output didn't match\nEXP: %s^\nGOT: %s^", exp, result)
+	}
+}
@@ -0,0 +1,165 @@
+package relog
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// ConfigWatcher keeps a running Relay configured from a config file, reloading the file when it changes
+// or when the process receives SIGHUP. Each reload builds a new tree from the file, then swaps the named
+// relay's receivers and settings into the running Relay in one step, so messages logged concurrently go either
+// to the previous tree or to the new one. The previous tree's outputs are closed after retireDelay, so that
+// messages already being forwarded to them when the trees are swapped are still written.
+// If the file can't be read or built, the error is logged to the Relay at severity Error and the previous tree is kept.
+type ConfigWatcher struct {
+	path  string
+	name  string
+	relay *Relay
+
+	mu      sync.Mutex // serializes reloads
+	tree    *Tree
+	retired []*Tree // previous trees, whose outputs are yet to be closed
+	modTime time.Time
+	size    int64
+
+	signals  chan os.Signal
+	stop     chan struct{}
+	stopOnce sync.Once
+	done     chan struct{}
+}
+
+// retireDelay is how long a ConfigWatcher waits after a reload before closing the previous tree's outputs.
+var retireDelay = time.Second
+
+// WatchConfig loads the config file at path, configures r as the relay called name in the file,
+// and checks the file for changes every interval (if interval is greater than zero) and on SIGHUP.
+// An error is returned if the initial load fails, in which case r is unchanged.
+func WatchConfig(path string, name string, r *Relay, interval time.Duration) (*ConfigWatcher, error) {
+	w := &ConfigWatcher{
+		path:    path,
+		name:    name,
+		relay:   r,
+		signals: make(chan os.Signal, 1),
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	if err := w.Reload(); err != nil {
+		return nil, err
+	}
+	signal.Notify(w.signals, syscall.SIGHUP)
+	go w.run(interval)
+	return w, nil
+}
+
+// run reloads the config on SIGHUP, or when polling finds it changed, until the watcher is closed.
+func (w *ConfigWatcher) run(interval time.Duration) {
+	defer close(w.done)
+	var tick <-chan time.Time
+	if interval > 0 {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+	for {
+		select {
+		case <-w.signals:
+			w.reload(true)
+		case <-tick:
+			w.reload(false)
+		case <-w.stop:
+			return
+		}
+	}
+}
+
+// reload reloads the config if force is set or the file has changed, logging any error to the Relay.
+func (w *ConfigWatcher) reload(force bool) {
+	if !force {
+		info, err := os.Stat(w.path)
+		if err != nil {
+			w.relay.Logf(LError, 1, "relog: config %s not reloaded: %v", w.path, err)
+			return
+		}
+		w.mu.Lock()
+		changed := !info.ModTime().Equal(w.modTime) || info.Size() != w.size
+		w.mu.Unlock()
+		if !changed {
+			return
+		}
+	}
+	if err := w.Reload(); err != nil {
+		w.relay.Logf(LError, 1, "relog: config %s not reloaded: %v", w.path, err)
+	}
+}
+
+// Reload loads the config file and swaps the tree it describes into the Relay. If the file can't be loaded,
+// the error is returned and the Relay is unchanged.
+func (w *ConfigWatcher) Reload() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	info, err := os.Stat(w.path)
+	if err != nil {
+		return err
+	}
+	// record the file as seen even if it is rejected, so that a bad config is reported once rather than every poll
+	w.modTime, w.size = info.ModTime(), info.Size()
+	tree, err := LoadConfig(w.path)
+	if err != nil {
+		return err
+	}
+	src := tree.Relay(w.name)
+	if src == nil {
+		tree.Close()
+		return fmt.Errorf("relog: config %s: no relay %q", w.path, w.name)
+	}
+	w.relay.replace(src)
+	if w.tree != nil {
+		w.retire(w.tree)
+	}
+	w.tree = tree
+	return nil
+}
+
+// retire closes t's outputs after retireDelay, unless the watcher is closed first. The caller must hold w.mu.
+func (w *ConfigWatcher) retire(t *Tree) {
+	w.retired = append(w.retired, t)
+	time.AfterFunc(retireDelay, func() {
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		for i, r := range w.retired {
+			if r == t {
+				w.retired = append(w.retired[:i], w.retired[i+1:]...)
+				t.Close()
+				return
+			}
+		}
+	})
+}
+
+// Tree returns the tree built from the config file most recently loaded.
+func (w *ConfigWatcher) Tree() *Tree {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.tree
+}
+
+// Close stops watching the config file and closes the outputs of the current tree, and of any previous trees
+// not yet closed. The Relay keeps its receivers, which should no longer be used.
+func (w *ConfigWatcher) Close() error {
+	w.stopOnce.Do(func() {
+		signal.Stop(w.signals)
+		close(w.stop)
+	})
+	<-w.done
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for _, t := range w.retired {
+		t.Close()
+	}
+	w.retired = nil
+	return w.tree.Close()
+}
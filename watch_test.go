@@ -0,0 +1,146 @@
+package relog
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestConfigWatcher(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "relog.json")
+	logA, logB := filepath.Join(dir, "a.log"), filepath.Join(dir, "b.log")
+	writeConfig := func(config string) {
+		if err := os.WriteFile(path, []byte(config), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeConfig(`{"relays": {"app": {"verbosity": "info", "receivers": ["a"]}},
+		"outputs": {"a": {"type": "file", "path": "` + logA + `", "verbosity": "debug", "flags": ""}}}`)
+
+	defer func(d time.Duration) { retireDelay = d }(retireDelay)
+	retireDelay = 50 * time.Millisecond
+	relay := New(LDebug, "", 0)
+	w, err := WatchConfig(path, "app", relay, 5*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	relay.Infof("to a")
+	relay.Debugf("dropped")
+	inFlight := relay.Receivers()
+
+	writeConfig(`{"relays": {"app": {"verbosity": "debug", "prefix": "app", "receivers": ["b"]}},
+		"outputs": {"b": {"type": "file", "path": "` + logB + `", "verbosity": "debug", "flags": ""}}}`)
+	for deadline := time.Now().Add(5 * time.Second); relay.Verbosity() != LDebug; time.Sleep(time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatal("config not reloaded")
+		}
+	}
+	relay.Debugf("to b")
+	for _, rcvr := range inFlight {
+		rcvr.Logf(LInfo, 1, "in flight") // as by a message forwarded while the trees were swapped
+	}
+	for deadline := time.Now().Add(5 * time.Second); ; time.Sleep(time.Millisecond) {
+		w.mu.Lock()
+		retired := len(w.retired)
+		w.mu.Unlock()
+		if retired == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("previous tree not closed")
+		}
+	}
+
+	writeConfig(`{"relays": {"app": {"verbosity": "loud"}}}`)
+	for deadline := time.Now().Add(5 * time.Second); ; time.Sleep(time.Millisecond) {
+		if b, _ := os.ReadFile(logB); len(b) > len("[DEBUG] app to b\n") {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("bad config not reported")
+		}
+	}
+	relay.Infof("still b")
+	w.Close()
+
+	exp := "[INFO] to a\n[INFO] in flight\n"
+	if b, _ := os.ReadFile(logA); string(b) != exp {
+		t.Errorf("First config output didn't match\nEXP: %s^\nGOT: %s^", exp, b)
+	}
+	exp = "[DEBUG] app to b\n[ERROR] app relog: config " + path + " not reloaded: relog: config relays.app.verbosity: invalid severity loud\n[INFO] app still b\n"
+	if b, _ := os.ReadFile(logB); string(b) != exp {
+		t.Errorf("Reloaded config output didn't match\nEXP: %s^\nGOT: %s^", exp, b)
+	}
+}
+
+func TestConfigWatcherNamed(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "relog.json")
+	config := `{"relays": {"app": {"verbosity": "warning", "prefix": "app"}}}`
+	if err := os.WriteFile(path, []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+	parent := New(LDebug, "svc", 0)
+	relay := parent.Named("app")
+	w, err := WatchConfig(path, "app", relay, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	parent.SetVerbosity(LError)
+	parent.SetPrefix("other")
+	if relay.Verbosity() != LWarn || relay.Prefix() != "app" {
+		t.Errorf("Configured settings didn't match\nEXP: %d %s^\nGOT: %d %s^", LWarn, "app", relay.Verbosity(), relay.Prefix())
+	}
+}
+
+func TestRelayReplace(t *testing.T) {
+	relay := New(LDebug, "old", 0)
+	relay.SetVModule("db/*=7")
+	relay.SetStackSeverity(LError)
+	relay.SetFallback(NewCollector(nil, LDebug, "", 0))
+
+	src := New(LInfo, "new", 0)
+	out := NewCollector(nil, LDebug, "", 0)
+	src.AddReceiver(out)
+	src.SetVModule("http/*=info")
+	relay.replace(src)
+
+	c := relay.settings()
+	if len(c.receivers) != 1 || c.receivers[0] != Receiver(out) || c.prefix != "new" || c.verbosity != LInfo ||
+		relay.VModule() != "http/*=info" || c.stack != NoStacks || c.errs != nil {
+		t.Errorf("Replaced settings didn't match source\nGOT: %+v^", *c)
+	}
+}
+
+func TestRelayReplaceConcurrent(t *testing.T) {
+	relay := New(LDebug, "", 0)
+	relay.AddReceiver(NewCollector(nil, LDebug, "", 0))
+	var srcs [2]*Relay
+	for i := range srcs {
+		prefix := strconv.Itoa(i)
+		srcs[i] = New(LDebug, prefix, 0)
+		srcs[i].AddReceiver(NewCollector(nil, LDebug, prefix, 0))
+	}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 1000; i++ {
+			relay.replace(srcs[i%2])
+		}
+	}()
+	for {
+		select {
+		case <-done:
+			return
+		default:
+		}
+		c := relay.settings()
+		if p := c.receivers[0].(*Collector).Prefix(); p != c.prefix {
+			t.Fatalf("Receivers and prefix from different sources: %q and %q", p, c.prefix)
+		}
+	}
+}
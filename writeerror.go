@@ -0,0 +1,138 @@
+package relog
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// WriteError reports that a Receiver failed to write a message forwarded by a Relay.
+type WriteError struct {
+	Receiver   Receiver
+	Err        error
+	Entry      *Entry // the message that failed; its File holds the full path of the caller, if known
+	Suppressed uint64 // failures of the Receiver not reported since its last WriteError
+}
+
+func (e *WriteError) Error() string {
+	return fmt.Sprintf("relog: writing to %T: %v", e.Receiver, e.Err)
+}
+
+func (e *WriteError) Unwrap() error { return e.Err }
+
+// errorHandling holds a Relay's handling of write errors.
+type errorHandling struct {
+	mu         sync.Mutex
+	handler    func(err *WriteError)
+	interval   time.Duration
+	fallback   Receiver
+	failures   map[interface{}]uint64 // keyed by receiverKey, as are reported and suppressed
+	reported   map[interface{}]time.Time
+	suppressed map[interface{}]uint64
+}
+
+// typeKey is the receiverKey of receivers not held by pointer.
+type typeKey struct{ t reflect.Type }
+
+// receiverKey returns the key under which rcvr's failures are counted: rcvr itself if it is a pointer, and otherwise
+// its type, so that receivers used by value, which may not be valid map keys even if their type is comparable,
+// as when they hold a func or slice in an interface field, never panic; receivers of the same such type share counts.
+func receiverKey(rcvr Receiver) interface{} {
+	if reflect.ValueOf(rcvr).Kind() == reflect.Ptr {
+		return rcvr
+	}
+	return typeKey{reflect.TypeOf(rcvr)}
+}
+
+// handle counts a failure of rcvr to write e, passes e to the fallback receiver, and reports err to the handler,
+// unless a failure of rcvr was reported within the interval.
+func (eh *errorHandling) handle(rcvr Receiver, err error, e *Entry) {
+	now := time.Now()
+	key := receiverKey(rcvr)
+	eh.mu.Lock()
+	eh.failures[key]++
+	handler, fallback := eh.handler, eh.fallback
+	var suppressed uint64
+	if handler != nil {
+		if last, ok := eh.reported[key]; ok && now.Sub(last) < eh.interval {
+			eh.suppressed[key]++
+			handler = nil
+		} else {
+			eh.reported[key] = now
+			suppressed = eh.suppressed[key]
+			delete(eh.suppressed, key)
+		}
+	}
+	eh.mu.Unlock()
+	if _, ok := key.(typeKey); fallback != nil && (ok || receiverKey(fallback) != key) {
+		logEntry(fallback, e)
+	}
+	if handler != nil {
+		handler(&WriteError{Receiver: rcvr, Err: err, Entry: e, Suppressed: suppressed})
+	}
+}
+
+// errorHandling returns the Relay's handling of write errors, or its parent's if it is a named child without its own,
+// or nil if neither an error handler nor a fallback is set.
+func (r *Relay) errorHandling() *errorHandling {
+	eh := r.cfg.Load().errs
+	if eh == nil && r.parent != nil {
+		return r.parent.errorHandling()
+	}
+	return eh
+}
+
+// setErrorHandling applies set to the Relay's handling of write errors, creating it if necessary,
+// and removes it if neither an error handler nor a fallback remains.
+func (r *Relay) setErrorHandling(set func(eh *errorHandling)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	eh := r.cfg.Load().errs
+	if eh == nil {
+		eh = &errorHandling{
+			failures:   make(map[interface{}]uint64),
+			reported:   make(map[interface{}]time.Time),
+			suppressed: make(map[interface{}]uint64),
+		}
+	}
+	eh.mu.Lock()
+	set(eh)
+	active := eh.handler != nil || eh.fallback != nil
+	eh.mu.Unlock()
+	if !active {
+		eh = nil
+	}
+	r.update(func(c *relayConfig) { c.errs = eh })
+}
+
+// SetErrorHandler sets a function to be called when one of the Relay's receivers fails to write a message.
+// To keep a persistently failing receiver from flooding the handler, failures of each receiver are reported
+// at most once per interval, with the number suppressed in between given in the next report.
+// Errors can only be detected from EntryReceivers, such as Collector and Relay, and from Output.
+// While an error handler or fallback is set, the Relay passes messages to EntryReceivers as Entries via
+// LogEntry, so that their errors are returned. A nil handler stops reporting.
+func SetErrorHandler(handler func(err *WriteError), interval time.Duration) {
+	std.SetErrorHandler(handler, interval)
+}
+func (r *Relay) SetErrorHandler(handler func(err *WriteError), interval time.Duration) {
+	r.setErrorHandling(func(eh *errorHandling) { eh.handler, eh.interval = handler, interval })
+}
+
+// SetFallback sets a Receiver, e.g. a Collector writing to stderr, to be passed the messages that the Relay's
+// receivers fail to write, once for each failing receiver. A nil fallback removes it.
+func SetFallback(rcvr Receiver) { std.SetFallback(rcvr) }
+func (r *Relay) SetFallback(rcvr Receiver) {
+	r.setErrorHandling(func(eh *errorHandling) { eh.fallback = rcvr })
+}
+
+// Failures returns the number of messages rcvr has failed to write for the Relay while it had an error handler or fallback set.
+func (r *Relay) Failures(rcvr Receiver) uint64 {
+	eh := r.errorHandling()
+	if eh == nil {
+		return 0
+	}
+	eh.mu.Lock()
+	defer eh.mu.Unlock()
+	return eh.failures[receiverKey(rcvr)]
+}
@@ -0,0 +1,92 @@
+package relog
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"regexp"
+	"testing"
+	"time"
+)
+
+// failingWriter fails every write.
+type failingWriter struct{}
+
+func (failingWriter) Write(p []byte) (int, error) { return 0, errors.New("disk full") }
+
+func TestRelayWriteErrors(t *testing.T) {
+	var output, fallback bytes.Buffer
+	failing := NewCollector(failingWriter{}, LDebug, "", 0)
+	working := NewCollector(&output, LDebug, "", 0)
+	relay := New(LDebug, "", 0)
+	relay.AddReceiver(failing)
+	relay.AddReceiver(working)
+
+	var reported []*WriteError
+	relay.SetErrorHandler(func(err *WriteError) { reported = append(reported, err) }, time.Hour)
+	relay.SetFallback(NewCollector(&fallback, LDebug, "", Lshortfile))
+
+	relay.Errorf("one")
+	relay.Warn("two")
+	relay.Output(2, "three")
+
+	exp := "[ERROR] one\n[WARNING] two\nthree\n"
+	if result := output.String(); result != exp {
+		t.Errorf("Working receiver output didn't match\nEXP: %s^\nGOT: %s^", exp, result)
+	}
+	expFallback := regexp.MustCompile(`^writeerror_test\.go:\d+: \[ERROR\] one\nwriteerror_test\.go:\d+: \[WARNING\] two\n\[NOTICE\] three\n$`)
+	if result := fallback.String(); !expFallback.MatchString(result) {
+		t.Errorf("Fallback output didn't match\nEXP: %s^\nGOT: %s^", expFallback, result)
+	}
+	if n := relay.Failures(failing); n != 3 {
+		t.Errorf("Failures didn't match\nEXP: %d^\nGOT: %d^", 3, n)
+	}
+	if n := relay.Failures(working); n != 0 {
+		t.Errorf("Failures didn't match\nEXP: %d^\nGOT: %d^", 0, n)
+	}
+	if len(reported) != 1 || reported[0].Receiver != failing || reported[0].Error() != "relog: writing to *relog.Collector: disk full" {
+		t.Fatalf("Reported errors didn't match: %v", reported)
+	}
+
+	relay.SetErrorHandler(func(err *WriteError) { reported = append(reported, err) }, 0)
+	relay.Errorf("four")
+	if len(reported) != 2 || reported[1].Suppressed != 2 || reported[1].Entry.Message != "four" {
+		t.Errorf("Suppressed errors weren't reported: %+v", reported[len(reported)-1])
+	}
+}
+
+// unhashableReceiver is a Receiver used by value whose type cannot be a map key.
+type unhashableReceiver struct {
+	*Collector
+	tags []string
+}
+
+// taggedReceiver is a Receiver used by value whose type is comparable, but which cannot be a map key
+// while its tag holds a func or slice.
+type taggedReceiver struct {
+	*Collector
+	tag interface{}
+}
+
+func TestRelayWriteErrorsUnhashable(t *testing.T) {
+	receivers := []func(w io.Writer) Receiver{
+		func(w io.Writer) Receiver { return unhashableReceiver{Collector: NewCollector(w, LDebug, "", 0)} },
+		func(w io.Writer) Receiver { return taggedReceiver{NewCollector(w, LDebug, "", 0), func() {}} },
+		func(w io.Writer) Receiver { return taggedReceiver{NewCollector(w, LDebug, "", 0), []string{"a"}} },
+	}
+	for _, newReceiver := range receivers {
+		var fallback bytes.Buffer
+		failing := newReceiver(failingWriter{})
+		relay := New(LDebug, "", 0)
+		relay.AddReceiver(failing)
+		relay.SetFallback(newReceiver(&fallback))
+
+		relay.Errorf("one")
+		if exp, result := "[ERROR] one\n", fallback.String(); result != exp {
+			t.Errorf("%T fallback output didn't match\nEXP: %s^\nGOT: %s^", failing, exp, result)
+		}
+		if n := relay.Failures(failing); n != 1 {
+			t.Errorf("%T failures didn't match\nEXP: %d^\nGOT: %d^", failing, 1, n)
+		}
+	}
+}